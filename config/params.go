@@ -12,4 +12,16 @@ var (
 	Port     = flag.String("port", "8080", "Port d'écoute du serveur")
 	DbFile   = flag.String("database", "database.db", "Fichier de base SQLite")
 	Firstrun = flag.Bool("init", false, "Création admin et 1er voucher")
+	KeyFile  = flag.String("key", "resa.key", "Fichier de la clé de signature des invitations (créé si absent)")
+	URL      = flag.String("url", "http://localhost:8080", "Adresse publique du site, utilisée dans les liens envoyés aux invités")
+
+	CodeLength = flag.Int("codelength", 10, "Nombre de caractères aléatoires des codes de voucher générés")
+	CodePrefix = flag.String("codeprefix", "", "Préfixe par défaut des codes de voucher générés")
+	MaxDepth   = flag.Int("maxdepth", 0, "Niveau maximum dans l'arbre de parrainage, compté depuis les vouchers des administrateurs (0 : illimité)")
+
+	SMTPServer    = flag.String("smtp", "", "Serveur SMTP (hôte:port) pour envoyer les mails, vide : les mails sont écrits dans le journal")
+	SMTPUser      = flag.String("smtpuser", "", "Utilisateur SMTP, vide : pas d'authentification")
+	SMTPPassword  = flag.String("smtppassword", "", "Mot de passe SMTP")
+	MailFrom      = flag.String("mailfrom", "resa@localhost", "Adresse d'expédition des mails")
+	ResetValidity = flag.Int("resetvalidity", 60, "Durée de validité en minutes des liens de réinitialisation du mot de passe")
 )
@@ -1,5 +1,10 @@
 function printDiv(divName) {
-     var printContents = document.getElementById(divName).innerHTML;
+     var printable = document.getElementById(divName).cloneNode(true);
+     var hidden = printable.getElementsByClassName("noprint");
+     while (hidden.length > 0) { // Declined invites aren't printed
+          hidden[0].parentNode.removeChild(hidden[0]);
+     }
+     var printContents = printable.innerHTML;
      var originalContents = document.body.innerHTML;
 
      document.body.innerHTML = printContents;
@@ -0,0 +1,47 @@
+// Search and collapse the referral tree.
+// A matching invite is highlighted and his parrains are opened so he can be seen.
+var search = document.getElementById('treesearch');
+var nodes = document.getElementsByClassName('node');
+
+function setOpen(open) {
+	var details = document.getElementById('tree').getElementsByTagName('details');
+	for (var i = 0; i < details.length; i++) {
+		details[i].open = open;
+	}
+}
+
+document.getElementById('expandall').onclick = function () {
+	setOpen(true);
+	return false;
+};
+
+document.getElementById('collapseall').onclick = function () {
+	setOpen(false);
+	return false;
+};
+
+search.onkeyup = function () {
+	var filter = search.value.toUpperCase();
+	if (filter === '') {
+		for (var i = 0; i < nodes.length; i++) {
+			nodes[i].classList.remove('found');
+		}
+		setOpen(true);
+		return;
+	}
+
+	setOpen(false);
+	for (var i = 0; i < nodes.length; i++) {
+		var found = nodes[i].getAttribute('data-search').toUpperCase().indexOf(filter) > -1;
+		nodes[i].classList.toggle('found', found);
+		if (found) { // Open every parrain up to the root
+			var parent = nodes[i].parentNode;
+			while (parent && parent.id !== 'tree') {
+				if (parent.tagName === 'DETAILS') {
+					parent.open = true;
+				}
+				parent = parent.parentNode;
+			}
+		}
+	}
+};
@@ -40,13 +40,7 @@ func inita() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Println("Admin added with sucess")
-
-	err = tools.CreateDefaultUser()
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Println("Default user added with sucess\nYou need to manualy add a voucher to this user via admin page.")
+	fmt.Println("Admin added with sucess\nYou need to create an event and a voucher via admin page.")
 }
 
 // main create the handle for every pages on the server. It links pages to related function.
@@ -62,14 +56,42 @@ func main() {
 		inita()
 	}
 
-	http.HandleFunc("/", web.Index)                        // Index and static files
-	http.HandleFunc("/connect", web.Connect)               // Connection and user page
-	http.HandleFunc("/register", web.Register)             // Handle the register page
-	http.HandleFunc("/disconnect", web.Disconnect)         // Delete session
-	http.HandleFunc("/admin", web.AdminIndex)              // Show admin page if cookie or login
-	http.HandleFunc("/adminconnect", web.AdminConnect)     // Handle connect admin form
-	http.HandleFunc("/addVoucher", web.AddVoucher)         // Add voucher to an invite
-	http.HandleFunc("/disableVoucher", web.DisableVoucher) // Disable a voucher to an invite
+	http.HandleFunc("/", web.Index)                          // Index and static files
+	http.HandleFunc("/connect", web.Connect)                 // Connection and user page
+	http.HandleFunc("/register", web.Register)               // Handle the register page
+	http.HandleFunc("/r/", web.RegisterLink)                 // Register form from a shared voucher link
+	http.HandleFunc("/disconnect", web.Disconnect)           // Delete session
+	http.HandleFunc("/admin", web.AdminIndex)                // Show admin page if cookie or login
+	http.HandleFunc("/adminconnect", web.AdminConnect)       // Handle connect admin form
+	http.HandleFunc("/addVoucher", web.AddVoucher)           // Add voucher to an invite
+	http.HandleFunc("/disableVoucher", web.DisableVoucher)   // Disable a voucher to an invite
+	http.HandleFunc("/addEvent", web.AddEvent)               // Create an event
+	http.HandleFunc("/cancel", web.Cancel)                   // Cancel the participation of the user
+	http.HandleFunc("/rsvp", web.Rsvp)                       // Change the RSVP answer of the user
+	http.HandleFunc("/addCompanion", web.AddCompanion)       // Add a companion to the user
+	http.HandleFunc("/deleteCompanion", web.DeleteCompanion) // Delete a companion of the user
+	http.HandleFunc("/removeInvite", web.RemoveInvite)       // Remove an invite from his event
+	http.HandleFunc("/qrcode.png", web.QRCode)               // QR code of the invitation
+	http.HandleFunc("/invitation.pdf", web.InvitationPDF)    // Invitation as a PDF document
+	http.HandleFunc("/invitation.ics", web.InvitationICS)    // Event as an iCalendar file
+	http.HandleFunc("/checkin", web.CheckIn)                 // Check invitations at the door (staff)
+	http.HandleFunc("/addStaff", web.AddStaff)               // Create a staff account
+	http.HandleFunc("/fields", web.Fields)                   // Registration questions of an event
+	http.HandleFunc("/deleteField", web.DeleteField)         // Delete a registration question
+	http.HandleFunc("/exportInvites", web.ExportInvites)     // Invites of an event as a CSV file
+	http.HandleFunc("/voucher", web.VoucherHistory)          // Registrations done with a voucher
+	http.HandleFunc("/editVoucher", web.EditVoucher)         // Change or re-enable a voucher
+	http.HandleFunc("/revokeChain", web.RevokeChain)         // Revoke a voucher and the invites who come from it
+	http.HandleFunc("/setQuota", web.SetQuota)               // Allow an invite to create his own vouchers
+	http.HandleFunc("/createVoucher", web.CreateVoucher)     // Voucher created by the user
+	http.HandleFunc("/revokeVoucher", web.RevokeVoucher)     // Disable a voucher of the user
+	http.HandleFunc("/tree", web.ReferralTree)               // Referral tree of the invites
+	http.HandleFunc("/tree.json", web.ReferralTreeJSON)      // Referral tree as a JSON file
+	http.HandleFunc("/tree.dot", web.ReferralTreeDOT)        // Referral tree as a Graphviz file
+	http.HandleFunc("/stats", web.ReferralStats)             // Referral leaderboard
+	http.HandleFunc("/stats.csv", web.ExportStats)           // Referral leaderboard as a CSV file
+	http.HandleFunc("/forgotPassword", web.ForgotPassword)   // Ask for a link to reset the password
+	http.HandleFunc("/resetPassword", web.ResetPassword)     // Choose a new password with the link
 
 	fmt.Println("Listening on " + *config.Port)
 	http.ListenAndServe(":"+*config.Port, nil)
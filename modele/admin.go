@@ -2,9 +2,17 @@ package modele
 
 // Admin is the adminitrateur modele.
 // Token is used to contrain the session token.
+// Role tells if it's a real admin or a staff account only allowed to check-in invites.
 type Admin struct {
 	IdAdmin int64
 	Login   string
 	Psw     string
 	Token   string
+	Role    string
 }
+
+// Roles of an Administrateur.
+const (
+	RoleAdmin = "admin"
+	RoleStaff = "staff"
+)
@@ -0,0 +1,68 @@
+package modele
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BuildICS build an iCalendar (RFC 5545) document with the event of an invite.
+// link is the address of the user page, added to the calendar entry.
+// The result can be sent as a .ics file or used as an email attachment.
+func BuildICS(event Event, invite Invite, link string) string {
+	const dateFormat = "20060102T150405Z" // UTC date as described in RFC 5545
+
+	description := "Invitation de " + invite.Prenom + " " + invite.Nom + " : " + link
+	if event.Description != "" {
+		description = event.Description + "\n" + description
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Resa//Invitation//FR",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:invite-" + strconv.FormatInt(invite.Id, 10) + "-event-" + strconv.FormatInt(event.Id, 10) + "@resa",
+		"DTSTAMP:" + time.Now().UTC().Format(dateFormat),
+		"DTSTART:" + event.Debut.UTC().Format(dateFormat),
+		"DTEND:" + event.Fin.UTC().Format(dateFormat),
+		"SUMMARY:" + escapeICS(event.Nom),
+		"LOCATION:" + escapeICS(event.Lieu),
+		"DESCRIPTION:" + escapeICS(description),
+		"URL:" + link,
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+
+	var ics strings.Builder
+	for _, line := range lines {
+		ics.WriteString(foldICS(line))
+	}
+	return ics.String()
+}
+
+// escapeICS escape a text value as required by RFC 5545.
+func escapeICS(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// foldICS end a content line with CRLF and split it so no line is longer than 75 octets.
+// Continuation lines start with a space. UTF-8 characters are never cut.
+func foldICS(line string) string {
+	var folded strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > 75 {
+			folded.WriteString("\r\n ")
+			length = 1
+		}
+		folded.WriteRune(r)
+		length += size
+	}
+	folded.WriteString("\r\n")
+	return folded.String()
+}
@@ -0,0 +1,13 @@
+package modele
+
+import "time"
+
+// CheckIn is the modele for the arrival of an invite at the event.
+// It keeps the staff account that has checked the invitation.
+type CheckIn struct {
+	Id         int64
+	Invite     int64
+	Date       time.Time
+	Staff      int64
+	StaffLogin string
+}
@@ -0,0 +1,70 @@
+package modele
+
+import "strings"
+
+// Alphabet of the generated voucher codes (Crockford base32).
+// I, L, O and U are left out so a code can be read over the phone or copied from paper.
+const CodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// The check character can also be one of these, the check is computed modulo 37.
+const codeCheckSymbols = CodeAlphabet + "*~$=U"
+
+// CleanCode remove spaces and dashes from a typed code and put it in upper case.
+func CleanCode(code string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(code))
+}
+
+// NormalizeCode put a typed code in his canonical form, the one used to compute the check character.
+// Letters that look like digits are replaced by the digit (I and L by 1, O by 0).
+func NormalizeCode(code string) string {
+	return strings.NewReplacer("I", "1", "L", "1", "O", "0").Replace(CleanCode(code))
+}
+
+// ValidPrefix tell if a prefix of generated codes is only made of upper case letters and digits.
+func ValidPrefix(prefix string) bool {
+	for _, c := range prefix {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// codeValue give the value of a character in the code alphabet, -1 if it isn't part of it.
+// U isn't in the alphabet but can be in a prefix, it counts as V.
+func codeValue(c rune) int {
+	if c == 'U' {
+		c = 'V'
+	}
+	return strings.IndexRune(CodeAlphabet, c)
+}
+
+// ValidCodeChars tell if the string only contains characters of the code alphabet (or U).
+func ValidCodeChars(s string) bool {
+	for _, c := range s {
+		if codeValue(c) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CodeCheckChar compute the check character of a code, the code being read as a base32 number.
+// The code needs to be normalized and made of the alphabet's characters.
+func CodeCheckChar(code string) byte {
+	sum := 0
+	for _, c := range code {
+		sum = (sum*32 + codeValue(c)) % 37
+	}
+	return codeCheckSymbols[sum]
+}
+
+// CheckCode tell if the last character of a normalized code is his check character.
+// The check character of generated codes is computed on the normalized prefix and random characters.
+// A single wrong character or two swapped characters are always detected.
+func CheckCode(code string) bool {
+	if len(code) < 2 || !ValidCodeChars(code[:len(code)-1]) {
+		return false
+	}
+	return CodeCheckChar(code[:len(code)-1]) == code[len(code)-1]
+}
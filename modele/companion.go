@@ -0,0 +1,12 @@
+package modele
+
+// Companion is a person coming with an invite (plus-one).
+// He only has a name and optional contact informations, he can't connect.
+type Companion struct {
+	Id     int64
+	Invite int64
+	Nom    string
+	Prenom string
+	Mail   string
+	Numtel string
+}
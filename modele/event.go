@@ -0,0 +1,60 @@
+package modele
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event is the modele for an event. Invites and vouchers are linked to one event.
+// It replace the hardcoded informations that used to be on the invitation.
+// Capacite is the maximum number of confirmed invites and companions, 0 means no limit.
+// MaxCompanions is the number of companions an invite can bring, unless his voucher says otherwise.
+// Auto is the automatic voucher policy, it can be overridden by each voucher.
+type Event struct {
+	Id            int64
+	Nom           string
+	Description   string
+	Lieu          string
+	Debut         time.Time
+	Fin           time.Time
+	Capacite      int
+	MaxCompanions int
+	Auto          Policy // Vouchers given to invites on registration
+}
+
+var jours = [...]string{"Dimanche", "Lundi", "Mardi", "Mercredi", "Jeudi", "Vendredi", "Samedi"}
+var mois = [...]string{"Janvier", "Février", "Mars", "Avril", "Mai", "Juin", "Juillet", "Août", "Septembre", "Octobre", "Novembre", "Décembre"}
+
+// FormatDate format a date in french. Example: Vendredi 29 Février 2019
+func FormatDate(t time.Time) string {
+	return fmt.Sprintf("%s %d %s %d", jours[t.Weekday()], t.Day(), mois[t.Month()-1], t.Year())
+}
+
+// FormatHeure format an hour in french. Example: 10h or 10h30
+func FormatHeure(t time.Time) string {
+	if t.Minute() == 0 {
+		return fmt.Sprintf("%dh", t.Hour())
+	}
+	return fmt.Sprintf("%dh%02d", t.Hour(), t.Minute())
+}
+
+// Horaires give the date and hours of the event as shown on the invitation.
+// Example: Vendredi 29 Février 2019 de 10h à 19h
+func (e Event) Horaires() string {
+	if e.Debut.Year() == e.Fin.Year() && e.Debut.YearDay() == e.Fin.YearDay() { // Event on a single day
+		return FormatDate(e.Debut) + " de " + FormatHeure(e.Debut) + " à " + FormatHeure(e.Fin)
+	}
+	return "Du " + FormatDate(e.Debut) + " " + FormatHeure(e.Debut) +
+		" au " + FormatDate(e.Fin) + " " + FormatHeure(e.Fin)
+}
+
+// GetEventNom using a list of event and an event id will return the event name.
+// This function is used to build the list of invite on the admin page.
+func GetEventNom(eventId int64, eventList []Event) string {
+	for _, element := range eventList {
+		if element.Id == eventId {
+			return element.Nom
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package modele
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Field is an extra question asked on registration, defined by the organizer for an event.
+// Options are the choices of a select field, separated by commas.
+type Field struct {
+	Id          int64
+	Event       int64
+	Libelle     string
+	Type        string
+	Options     string
+	Obligatoire bool
+}
+
+// Answer is the answer of an invite to a field. Libelle is a copy of the field's, used to show answers.
+type Answer struct {
+	Field   int64
+	Invite  int64
+	Libelle string
+	Valeur  string
+}
+
+// Types of field.
+const (
+	FieldTexte  = "texte"
+	FieldSelect = "select"
+	FieldCase   = "case" // Checkbox, the answer is "oui" or empty
+)
+
+// ValidFieldType tell if the type is one of the field types.
+func ValidFieldType(fieldType string) bool {
+	return fieldType == FieldTexte || fieldType == FieldSelect || fieldType == FieldCase
+}
+
+// Choix return the choices of a select field.
+func (f Field) Choix() []string {
+	var choix []string
+	for _, option := range strings.Split(f.Options, ",") {
+		option = strings.TrimSpace(option)
+		if option != "" {
+			choix = append(choix, option)
+		}
+	}
+	return choix
+}
+
+// FormName is the name of the field's input in the register form.
+func (f Field) FormName() string {
+	return "field_" + strconv.FormatInt(f.Id, 10)
+}
+
+// CheckAnswer check the answer to a field. It needs to be given if the field is required and be one of the choices of a select.
+func (f Field) CheckAnswer(valeur string) error {
+	if f.Obligatoire && valeur == "" {
+		return errors.New("Field required")
+	}
+	if f.Type == FieldSelect && valeur != "" {
+		for _, option := range f.Choix() {
+			if option == valeur {
+				return nil
+			}
+		}
+		return errors.New("Invalid choice")
+	}
+	return nil
+}
@@ -5,19 +5,60 @@ package modele
 
 import (
 	"regexp"
+	"time"
 )
 
 // Invite is the datastructure for invite. Mirror of invite on database.
 // Links to parrain using parrain id isn't made her.
 type Invite struct {
-	Id      int64
-	Nom     string
-	Prenom  string
-	Mail    string
-	Mdp     string
-	Numtel  string
-	Parrain int64
-	Voucher string
+	Id              int64
+	Nom             string
+	Prenom          string
+	Mail            string
+	Mdp             string
+	Numtel          string
+	Parrain         int64
+	Event           int64
+	Statut          string
+	MaxCompanions   int       // Number of companions allowed, given by the voucher or the event
+	Rsvp            string    // Answer of the invite: attending, declined or maybe
+	RsvpDate        time.Time // Last time the answer has changed
+	VoucherQuota    int       // Number of vouchers the invite can still create himself
+	QuotaExpiration time.Time // Vouchers created by the invite can't expire after this date
+	Depth           int       // Level in the referral tree, invites of a root voucher are at 1
+}
+
+// Invite status. An invite is confirmed unless the event is full, he's then on the waitlist.
+// A cancelled invite has given up his place, it's given to the first invite of the waitlist.
+// A revoked invite was excluded by an admin, for example because he registered with a leaked code.
+const (
+	StatutConfirme = "confirme"
+	StatutAttente  = "attente"
+	StatutAnnule   = "annule"
+	StatutRevoque  = "revoque"
+)
+
+// Termine tell if the invite doesn't take part in the event anymore (cancelled or revoked).
+func (i Invite) Termine() bool {
+	return i.Statut == StatutAnnule || i.Statut == StatutRevoque
+}
+
+// CanSponsor tell if invites registering with a voucher of this invite stay within the maximum depth of the referral tree.
+// maxDepth 0 means no limit.
+func (i Invite) CanSponsor(maxDepth int) bool {
+	return maxDepth <= 0 || i.Depth < maxDepth
+}
+
+// RSVP answers of an invite. Registering means attending, the invite can change his mind later.
+const (
+	RsvpPresent  = "present"
+	RsvpAbsent   = "absent"
+	RsvpPeutEtre = "peutetre"
+)
+
+// ValidRsvp tell if the answer is one of the RSVP answers.
+func ValidRsvp(rsvp string) bool {
+	return rsvp == RsvpPresent || rsvp == RsvpAbsent || rsvp == RsvpPeutEtre
 }
 
 // CheckMail check email formatting using a regex.
@@ -0,0 +1,62 @@
+package modele
+
+import (
+	"strconv"
+	"time"
+)
+
+// Policy is the automatic voucher issuance policy of an event or of a voucher.
+// When it's enabled, every invite registering gets his own voucher right away so he can invite people without waiting for an admin.
+// MaxUses is the number of registrations allowed with the voucher created, 0 means no automatic voucher.
+// Days is how long the voucher created is valid, 0 means until the end of the event.
+// MaxDepth is the deepest level of the referral tree getting a voucher, 0 means no limit.
+type Policy struct {
+	MaxUses  int
+	Days     int
+	MaxDepth int
+}
+
+// Enabled tell if vouchers are created automatically.
+func (p Policy) Enabled() bool {
+	return p.MaxUses > 0
+}
+
+// Allows tell if an invite at this depth of the referral tree gets a voucher.
+func (p Policy) Allows(depth int) bool {
+	return p.Enabled() && (p.MaxDepth <= 0 || depth <= p.MaxDepth)
+}
+
+// Expiration give the expiration date of a voucher created now for the event.
+func (p Policy) Expiration(e Event) time.Time {
+	if p.Days <= 0 {
+		return e.Fin
+	}
+	return time.Now().AddDate(0, 0, p.Days)
+}
+
+// Describe the policy as shown on the admin page.
+// Example: 3 utilisations, 7 jours, jusqu'au niveau 4
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return "aucun"
+	}
+	s := strconv.Itoa(p.MaxUses) + " utilisations"
+	if p.Days > 0 {
+		s += ", " + strconv.Itoa(p.Days) + " jours"
+	} else {
+		s += ", jusqu'à la fin de l'événement"
+	}
+	if p.MaxDepth > 0 {
+		s += ", jusqu'au niveau " + strconv.Itoa(p.MaxDepth)
+	}
+	return s
+}
+
+// Policy give the automatic voucher policy of invites registering with this voucher.
+// The voucher's own policy is used unless his MaxUses is -1, then it's the event's.
+func (v Voucher) Policy(e Event) Policy {
+	if v.Auto.MaxUses < 0 {
+		return e.Auto
+	}
+	return v.Auto
+}
@@ -0,0 +1,16 @@
+package modele
+
+import "time"
+
+// Redemption is the modele for the use of a voucher to register.
+// It keeps the IP address of the registration. Nom, Prenom and Mail are the invite's, used to show the history.
+type Redemption struct {
+	Id      int64
+	Voucher int64
+	Invite  int64
+	Date    time.Time
+	IP      string
+	Nom     string
+	Prenom  string
+	Mail    string
+}
@@ -0,0 +1,28 @@
+package modele
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Referral is an invite sponsored by the connected user, as shown on his page.
+// Only the first name and the initial of the name are kept for privacy.
+// Code is the voucher he used, empty if it wasn't recorded. Date is zero if unknown.
+type Referral struct {
+	Prenom   string
+	Initiale string
+	Code     string
+	Date     time.Time
+}
+
+// NewReferral build a referral from the name of the invite, only keeping the initial of the name.
+func NewReferral(prenom string, nom string, code string, date time.Time) Referral {
+	initiale := ""
+	nom = strings.TrimSpace(nom)
+	if nom != "" {
+		r, _ := utf8.DecodeRuneInString(nom)
+		initiale = strings.ToUpper(string(r)) + "."
+	}
+	return Referral{prenom, initiale, code, date}
+}
@@ -0,0 +1,121 @@
+package modele
+
+import "sort"
+
+// VoucherStats count the registrations done with a voucher and how many of them confirmed or came to the event.
+type VoucherStats struct {
+	V         Voucher
+	Inscrits  int // Registrations recorded with this voucher
+	Confirmes int
+	Presents  int // Checked-in at the door
+}
+
+// Conversion is the percentage of registrations with the voucher that came to the event.
+func (s VoucherStats) Conversion() int {
+	if s.Inscrits == 0 {
+		return 0
+	}
+	return s.Presents * 100 / s.Inscrits
+}
+
+// ParrainStats is a line of the referral leaderboard.
+// Directs are the invites he sponsored himself, Downstream counts every invite below him in the referral tree.
+// Confirmes and Presents only count the direct referrals.
+type ParrainStats struct {
+	Rang       int // Position in the leaderboard, set by SortStats
+	I          Invite
+	Directs    int
+	Downstream int
+	Confirmes  int
+	Presents   int
+	Vouchers   []VoucherStats
+}
+
+// Columns the leaderboard can be sorted by.
+var StatsSorts = []string{"directs", "downstream", "confirmes", "presents"}
+
+// BuildStats compute the referral statistics of every invite who has a voucher or has sponsored someone.
+// redemptions associate an invite with the voucher he used to register.
+func BuildStats(inviteList []Invite, vouchers map[int64][]Voucher, checkIns map[int64]CheckIn, redemptions map[int64]int64) []ParrainStats {
+	sizes := make(map[int64]int) // Size of the subtree of every invite
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		sizes[n.Id] = n.Size
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	for _, root := range BuildTree(inviteList) {
+		walk(root)
+	}
+
+	byVoucher := make(map[int64]*VoucherStats)
+	stats := make(map[int64]*ParrainStats)
+	var list []*ParrainStats
+	for _, element := range inviteList {
+		s := &ParrainStats{I: element, Downstream: sizes[element.Id] - 1}
+		for _, voucher := range vouchers[element.Id] {
+			s.Vouchers = append(s.Vouchers, VoucherStats{V: voucher})
+		}
+		for i := range s.Vouchers {
+			byVoucher[s.Vouchers[i].V.Id] = &s.Vouchers[i]
+		}
+		stats[element.Id] = s
+		list = append(list, s)
+	}
+
+	for _, element := range inviteList {
+		_, present := checkIns[element.Id]
+		confirme := element.Statut == StatutConfirme
+		if parrain, found := stats[element.Parrain]; found && element.Parrain != element.Id {
+			parrain.Directs++
+			if confirme {
+				parrain.Confirmes++
+			}
+			if present {
+				parrain.Presents++
+			}
+		}
+		if voucher, found := byVoucher[redemptions[element.Id]]; found {
+			voucher.Inscrits++
+			if confirme {
+				voucher.Confirmes++
+			}
+			if present {
+				voucher.Presents++
+			}
+		}
+	}
+
+	var result []ParrainStats
+	for _, s := range list {
+		if s.Directs > 0 || len(s.Vouchers) > 0 {
+			result = append(result, *s)
+		}
+	}
+	return result
+}
+
+// SortStats sort the leaderboard by one of StatsSorts, best first. Ties are sorted by direct referrals.
+func SortStats(stats []ParrainStats, by string) {
+	value := func(s ParrainStats) int {
+		switch by {
+		case "downstream":
+			return s.Downstream
+		case "confirmes":
+			return s.Confirmes
+		case "presents":
+			return s.Presents
+		}
+		return s.Directs
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if value(stats[i]) != value(stats[j]) {
+			return value(stats[i]) > value(stats[j])
+		}
+		return stats[i].Directs > stats[j].Directs
+	})
+	for i := range stats {
+		stats[i].Rang = i + 1
+	}
+}
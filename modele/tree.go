@@ -0,0 +1,145 @@
+package modele
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is an invite in the referral tree, with the invites he has sponsored as children.
+// Depth is the level of the invite in the referral tree, numbered like Invite.Depth: invites of a root voucher are at 1.
+// Size counts the invites of the subtree, the node included.
+// It is exported as JSON, the password and contact details other than the mail aren't part of it.
+type Node struct {
+	Id       int64   `json:"id"`
+	Nom      string  `json:"nom"`
+	Prenom   string  `json:"prenom"`
+	Mail     string  `json:"mail"`
+	Event    int64   `json:"event"`
+	Statut   string  `json:"statut"`
+	Depth    int     `json:"depth"`
+	Size     int     `json:"size"`
+	Children []*Node `json:"children"`
+}
+
+// BuildTree build the referral tree from a list of invites using Invite.Parrain.
+// Invites whose parrain isn't in the list are roots. Return the roots in the order of the list.
+func BuildTree(inviteList []Invite) []*Node {
+	nodes := make(map[int64]*Node)
+	for _, element := range inviteList {
+		nodes[element.Id] = &Node{
+			Id:       element.Id,
+			Nom:      element.Nom,
+			Prenom:   element.Prenom,
+			Mail:     element.Mail,
+			Event:    element.Event,
+			Statut:   element.Statut,
+			Depth:    element.Depth,
+			Children: []*Node{},
+		}
+	}
+
+	var roots []*Node
+	for _, element := range inviteList {
+		parrain, found := nodes[element.Parrain]
+		if found && element.Parrain != element.Id {
+			parrain.Children = append(parrain.Children, nodes[element.Id])
+		} else {
+			roots = append(roots, nodes[element.Id])
+		}
+	}
+
+	visited := make(map[int64]bool) // A broken database could have a loop, don't follow it forever
+	for _, root := range roots {
+		computeNode(root, 1, visited)
+	}
+	return roots
+}
+
+// computeNode fill the size of a node and his children.
+// The depth is only computed for invites registered before it was stored, the given depth is the one of their place in the tree.
+func computeNode(n *Node, depth int, visited map[int64]bool) int {
+	visited[n.Id] = true
+	if n.Depth == 0 {
+		n.Depth = depth
+	}
+	n.Size = 1
+	for _, child := range n.Children {
+		if !visited[child.Id] {
+			n.Size += computeNode(child, n.Depth+1, visited)
+		}
+	}
+	return n.Size
+}
+
+// BuildDOT describe the referral tree in the Graphviz DOT language. Each invite is labelled with his name and mail.
+func BuildDOT(roots []*Node) string {
+	var b strings.Builder
+	b.WriteString("digraph parrainage {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	visited := make(map[int64]bool)
+	for _, root := range roots {
+		writeDOTNode(&b, root, visited)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// writeDOTNode write a node, the edges to his children and then the children.
+func writeDOTNode(b *strings.Builder, n *Node, visited map[int64]bool) {
+	if visited[n.Id] {
+		return
+	}
+	visited[n.Id] = true
+	fmt.Fprintf(b, "\tn%d [label=\"%s\"];\n", n.Id, escapeDOT(n.Prenom+" "+n.Nom+"\n"+n.Mail))
+	for _, child := range n.Children {
+		fmt.Fprintf(b, "\tn%d -> n%d;\n", n.Id, child.Id)
+	}
+	for _, child := range n.Children {
+		writeDOTNode(b, child, visited)
+	}
+}
+
+// escapeDOT escape a string to be used in a quoted DOT label.
+func escapeDOT(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// Downstream is an invite reached from a voucher through the referral chain.
+// Depth is 1 for the invites who registered with the voucher, 2 for the ones they sponsored and so on.
+type Downstream struct {
+	I     Invite
+	Depth int
+}
+
+// FindDownstream return the given invites and every invite they have sponsored, directly or not.
+// The list is in the order of the referral chain, each invite is followed by his sponsored invites.
+func FindDownstream(inviteList []Invite, ids []int64) []Downstream {
+	invites := make(map[int64]Invite)
+	children := make(map[int64][]int64)
+	for _, element := range inviteList {
+		invites[element.Id] = element
+		if element.Parrain != element.Id {
+			children[element.Parrain] = append(children[element.Parrain], element.Id)
+		}
+	}
+
+	var list []Downstream
+	visited := make(map[int64]bool)
+	var visit func(id int64, depth int)
+	visit = func(id int64, depth int) {
+		invite, found := invites[id]
+		if !found || visited[id] {
+			return
+		}
+		visited[id] = true
+		list = append(list, Downstream{invite, depth})
+		for _, child := range children[id] {
+			visit(child, depth+1)
+		}
+	}
+	for _, id := range ids {
+		visit(id, 1)
+	}
+	return list
+}
@@ -1,11 +1,82 @@
 package modele
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // Voucher is the modele for vouchers. It has a proprietary and an expiration date.
+// It can only be used after NotBefore, the zero time means as soon as it is created.
+// Invites registering with a voucher are registered to the voucher's event.
+// MaxCompanions is the number of companions of invites registering with this voucher, -1 means use the event's.
+// MaxUses is the number of registrations allowed with this voucher, 0 means no limit. Used counts them.
+// Statut tells if the voucher was disabled by an admin.
+// Issuer is the id_admin of the admin who issued the voucher himself, Prop is then 0. It's 0 for vouchers owned by an invite.
 type Voucher struct {
-	Id         int64
-	Code       string
-	Expiration time.Time
-	Prop       int64
+	Id            int64
+	Code          string
+	Expiration    time.Time
+	Prop          int64
+	Event         int64
+	MaxCompanions int
+	MaxUses       int
+	Used          int
+	Statut        string
+	NotBefore     time.Time
+	Auto          Policy // Vouchers given to invites registering with this one, MaxUses -1 means use the event's
+	Issuer        int64
+}
+
+// Status of a voucher.
+const (
+	VoucherActif     = "actif"
+	VoucherDesactive = "desactive"
+)
+
+// Disabled tell if the voucher was disabled by an admin.
+func (v Voucher) Disabled() bool {
+	return v.Statut == VoucherDesactive
+}
+
+// Etat describe the status of the voucher, as shown on the admin and user pages.
+func (v Voucher) Etat() string {
+	if v.Disabled() {
+		return "Désactivé"
+	}
+	if v.NotBefore.After(time.Now()) {
+		return "À venir"
+	}
+	if !v.Expiration.After(time.Now()) {
+		return "Expiré"
+	}
+	if v.UsedUp() {
+		return "Épuisé"
+	}
+	return "Actif"
+}
+
+// UsedUp tell if the voucher can't be used anymore because of his redemption limit.
+func (v Voucher) UsedUp() bool {
+	return v.MaxUses > 0 && v.Used >= v.MaxUses
+}
+
+// Remaining describe how many registrations are still possible with the voucher.
+// Example: 7 restantes or illimité
+func (v Voucher) Remaining() string {
+	if v.MaxUses <= 0 {
+		return "illimité"
+	}
+	if v.UsedUp() {
+		return "0 restante"
+	}
+	return strconv.Itoa(v.MaxUses-v.Used) + " restantes"
+}
+
+// Usage describe how many times the voucher was used, as shown on the admin page.
+// Example: 3/10 utilisés or 3 utilisés without limit
+func (v Voucher) Usage() string {
+	if v.MaxUses > 0 {
+		return strconv.Itoa(v.Used) + "/" + strconv.Itoa(v.MaxUses) + " utilisés"
+	}
+	return strconv.Itoa(v.Used) + " utilisés"
 }
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/mattn/go-sqlite3"
+	"time"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// CheckInInvite record the arrival of an invite, checked by a staff account.
+// An invite can only be checked-in once: if he already was, the first check-in is returned with an error.
+// It's also the case when two staff accounts check the same invite at once, the UNIQUE constraint refuses the second one.
+func CheckInInvite(db *sql.DB, idInvite int64, idStaff int64) (modele.CheckIn, error) {
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return modele.CheckIn{}, err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	checkIn, err := getCheckIn(tx, idInvite)
+	if err == nil {
+		return checkIn, errors.New("Already checked in")
+	}
+	if err != sql.ErrNoRows {
+		return checkIn, err
+	}
+
+	checkIn = modele.CheckIn{
+		Invite: idInvite,
+		Date:   time.Now(),
+		Staff:  idStaff,
+	}
+	result, err := tx.Exec("INSERT INTO CheckIn(invite,date,staff) VALUES (?,?,?)",
+		checkIn.Invite, checkIn.Date, checkIn.Staff)
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		tx.Rollback()                           // Someone else checked him in since the select
+		checkIn, err = getCheckIn(db, idInvite) // Show the first check-in
+		if err != nil {
+			return checkIn, err
+		}
+		return checkIn, errors.New("Already checked in")
+	}
+	if err != nil {
+		return checkIn, err
+	}
+	checkIn.Id, err = result.LastInsertId()
+	if err != nil {
+		return checkIn, err
+	}
+
+	return checkIn, tx.Commit()
+}
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getCheckIn return the check-in of an invite or sql.ErrNoRows if he hasn't come yet.
+func getCheckIn(q rowQuerier, idInvite int64) (modele.CheckIn, error) {
+	var checkIn modele.CheckIn
+	err := q.QueryRow("SELECT id_checkin,invite,date,staff,login"+
+		" FROM CheckIn,Administrateur"+
+		" WHERE staff = id_admin AND invite = ?", idInvite).Scan(
+		&checkIn.Id,
+		&checkIn.Invite,
+		&checkIn.Date,
+		&checkIn.Staff,
+		&checkIn.StaffLogin,
+	)
+	return checkIn, err
+}
+
+// GetCheckIns extract all check-ins from database in an HashMap associating userId with his check-in.
+// Info from database can be **empty** but **can't be nil**!!
+func GetCheckIns(db *sql.DB, checkIns map[int64]modele.CheckIn) error {
+	result, err := db.Query("SELECT id_checkin,invite,date,staff,login" +
+		" FROM CheckIn,Administrateur" +
+		" WHERE staff = id_admin")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var checkInTmp modele.CheckIn
+		err = result.Scan(
+			&checkInTmp.Id,
+			&checkInTmp.Invite,
+			&checkInTmp.Date,
+			&checkInTmp.Staff,
+			&checkInTmp.StaffLogin,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+		checkIns[checkInTmp.Invite] = checkInTmp // Build the map with every check-ins, associate with id_invite
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
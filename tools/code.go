@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+
+	"github.com/DucNg/resa/config"
+	"github.com/DucNg/resa/modele"
+)
+
+// GenerateCode generate a random voucher code using crypto/rand.
+// The code is the prefix followed by length random characters of the code alphabet and a check character.
+func GenerateCode(prefix string, length int) (string, error) {
+	if !modele.ValidPrefix(prefix) {
+		return "", errors.New("Invalid prefix")
+	}
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.New("Error generating random")
+	}
+	code := []byte(prefix)
+	for _, r := range b {
+		code = append(code, modele.CodeAlphabet[r%32]) // 256 is a multiple of 32, every character is as likely
+	}
+	return string(code) + string(modele.CodeCheckChar(modele.NormalizeCode(string(code)))), nil
+}
+
+// GenerateVouchers insert count vouchers with generated codes using the voucher modele for the other informations.
+// Codes already used by another voucher are generated again.
+// Return the generated codes.
+func GenerateVouchers(db *sql.DB, voucher modele.Voucher, prefix string, length int, count int) ([]string, error) {
+	var codes []string
+	for len(codes) < count {
+		code, err := GenerateCode(prefix, length)
+		if err != nil {
+			return codes, err
+		}
+		_, err = GetVoucher(db, code)
+		if err == nil { // Already exists, try another one
+			continue
+		}
+		if err.Error() != "Voucher doesn't exist" {
+			return codes, err
+		}
+
+		voucher.Code = code
+		err = AddVoucher(db, voucher)
+		if err != nil {
+			return codes, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// FindVoucherCode return the code of the voucher as stored in database from the code typed by the user.
+// Codes typed by an admin are looked for as they are, generated codes can be typed in lower case or with dashes.
+// Return "Voucher mistyped" if the code doesn't exist, is as long as a generated code and his check character is wrong.
+func FindVoucherCode(db *sql.DB, code string) (string, error) {
+	_, err := GetVoucher(db, code)
+	if err == nil || err.Error() != "Voucher doesn't exist" {
+		return code, err
+	}
+
+	cleaned := modele.CleanCode(code)
+	_, err = GetVoucher(db, cleaned)
+	if err == nil || err.Error() != "Voucher doesn't exist" {
+		return cleaned, err
+	}
+
+	normalized := modele.NormalizeCode(code) // Letters typed instead of digits
+	_, err = GetVoucher(db, normalized)
+	if err == nil || err.Error() != "Voucher doesn't exist" {
+		return normalized, err
+	}
+	if len(normalized) > *config.CodeLength && !modele.CheckCode(normalized) { // Only codes long enough to be generated ones have a check character
+		return code, errors.New("Voucher mistyped")
+	}
+	return code, err
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// AddCompanion add a companion to an invite using a modele.
+// The invite can't have more companions than allowed and a confirmed invite needs a free place for him.
+// Return the inserted id or an error: "Too many companions" or "Event full".
+func AddCompanion(db *sql.DB, companion modele.Companion) (int64, error) {
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	var maxCompanions, companions int
+	var idEvent int64
+	var statut string
+	err = tx.QueryRow("SELECT max_companions,event,statut FROM Invite WHERE id_invite = ?",
+		companion.Invite).Scan(&maxCompanions, &idEvent, &statut)
+	if err != nil {
+		return -1, err
+	}
+	err = tx.QueryRow("SELECT COUNT(*) FROM Companion WHERE invite = ?", companion.Invite).Scan(&companions)
+	if err != nil {
+		return -1, err
+	}
+	if companions >= maxCompanions {
+		return -1, errors.New("Too many companions")
+	}
+
+	if statut == modele.StatutConfirme { // Waitlisted invites take their places when promoted
+		enough, err := hasPlaces(tx, idEvent, 1)
+		if err != nil {
+			return -1, err
+		}
+		if !enough {
+			return -1, errors.New("Event full")
+		}
+	}
+
+	result, err := tx.Exec("INSERT INTO Companion(invite,nom,prenom,mail,numtel) VALUES (?,?,?,?,?)",
+		companion.Invite, companion.Nom, companion.Prenom, companion.Mail, companion.Numtel)
+	if err != nil {
+		return -1, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	return id, tx.Commit()
+}
+
+// DeleteCompanion delete a companion of an invite. The invite id is needed so an invite can't delete others companions.
+// The place is given to the waitlist.
+func DeleteCompanion(db *sql.DB, idCompanion int64, invite modele.Invite) error {
+	_, err := db.Exec("DELETE FROM Companion WHERE id_companion = ? AND invite = ?", idCompanion, invite.Id)
+	if err != nil {
+		return err
+	}
+	return PromoteWaitlist(db, invite.Event)
+}
+
+// ListCompanions fill the slice with the companions of an invite.
+// Info from database can be **empty** but **can't be nil**!!
+func ListCompanions(db *sql.DB, idInvite int64, listC *[]modele.Companion) error {
+	result, err := db.Query("SELECT id_companion,invite,nom,prenom,mail,numtel"+
+		" FROM Companion WHERE invite = ? ORDER BY id_companion", idInvite)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var companionTmp modele.Companion
+		err = result.Scan(
+			&companionTmp.Id,
+			&companionTmp.Invite,
+			&companionTmp.Nom,
+			&companionTmp.Prenom,
+			&companionTmp.Mail,
+			&companionTmp.Numtel,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+
+		*listC = append(*listC, companionTmp)
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
+
+// GetCompanions extract all companions from database in an HashMap associating userId with his companions.
+// Info from database can be **empty** but **can't be nil**!!
+func GetCompanions(db *sql.DB, companions map[int64][]modele.Companion) error {
+	result, err := db.Query("SELECT id_companion,invite,nom,prenom,mail,numtel" +
+		" FROM Companion ORDER BY id_companion")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var companionTmp modele.Companion
+		err = result.Scan(
+			&companionTmp.Id,
+			&companionTmp.Invite,
+			&companionTmp.Nom,
+			&companionTmp.Prenom,
+			&companionTmp.Mail,
+			&companionTmp.Numtel,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+		companions[companionTmp.Invite] = append(companions[companionTmp.Invite], companionTmp) // Associate with id_invite
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
@@ -16,6 +16,14 @@ DROP TABLE Session;
 DROP TABLE AdminSession;
 DROP TABLE Invite;
 DROP TABLE Administrateur;
+DROP TABLE Event;
+DROP TABLE CheckIn;
+DROP TABLE Companion;
+DROP TABLE Rsvp;
+DROP TABLE Field;
+DROP TABLE Answer;
+DROP TABLE Redemption;
+DROP TABLE PasswordReset;
 
 CREATE TABLE Invite (
 	id_invite INTEGER PRIMARY KEY,
@@ -24,7 +32,34 @@ CREATE TABLE Invite (
 	mail TEXT NOT NULL,
 	mdp TEXT NOT NULL,
 	numtel TEXT,
-	parrain INTEGER REFERENCES id_invite
+	parrain INTEGER REFERENCES id_invite,
+	event INTEGER,
+	statut TEXT NOT NULL,
+	max_companions INTEGER,
+	rsvp TEXT NOT NULL,
+	rsvp_date TIMESTAMP,
+	voucher_quota INTEGER DEFAULT 0,
+	quota_expiration TIMESTAMP,
+	depth INTEGER DEFAULT 0,
+	FOREIGN KEY (event) REFERENCES Event(id_event)
+);
+
+CREATE TABLE Rsvp (
+	id_rsvp INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL,
+	etat TEXT NOT NULL,
+	date TIMESTAMP,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
+);
+
+CREATE TABLE Companion (
+	id_companion INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL,
+	nom TEXT,
+	prenom TEXT,
+	mail TEXT,
+	numtel TEXT,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
 );
 
 CREATE TABLE Voucher (
@@ -32,13 +67,87 @@ CREATE TABLE Voucher (
 	code TEXT,
 	expiration TIMESTAMP,
 	proprietaire INTEGER,
-	FOREIGN KEY (proprietaire) REFERENCES Invite(id_invite)
+	event INTEGER,
+	max_companions INTEGER,
+	max_uses INTEGER DEFAULT 0,
+	used INTEGER DEFAULT 0,
+	statut TEXT DEFAULT 'actif',
+	not_before TIMESTAMP,
+	auto_uses INTEGER DEFAULT -1,
+	auto_days INTEGER DEFAULT 0,
+	auto_depth INTEGER DEFAULT 0,
+	issuer INTEGER DEFAULT 0,
+	FOREIGN KEY (proprietaire) REFERENCES Invite(id_invite),
+	FOREIGN KEY (issuer) REFERENCES Administrateur(id_admin),
+	FOREIGN KEY (event) REFERENCES Event(id_event)
+);
+
+CREATE TABLE Event (
+	id_event INTEGER PRIMARY KEY,
+	nom TEXT NOT NULL,
+	description TEXT,
+	lieu TEXT,
+	debut TIMESTAMP,
+	fin TIMESTAMP,
+	capacite INTEGER,
+	max_companions INTEGER,
+	auto_uses INTEGER DEFAULT 0,
+	auto_days INTEGER DEFAULT 0,
+	auto_depth INTEGER DEFAULT 0
+);
+
+CREATE TABLE Field (
+	id_field INTEGER PRIMARY KEY,
+	event INTEGER NOT NULL,
+	libelle TEXT NOT NULL,
+	type TEXT NOT NULL,
+	options TEXT,
+	obligatoire BOOLEAN,
+	FOREIGN KEY (event) REFERENCES Event(id_event)
+);
+
+CREATE TABLE Answer (
+	field INTEGER NOT NULL,
+	invite INTEGER NOT NULL,
+	valeur TEXT,
+	PRIMARY KEY (field, invite),
+	FOREIGN KEY (field) REFERENCES Field(id_field),
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
+);
+
+CREATE TABLE Redemption (
+	id_redemption INTEGER PRIMARY KEY,
+	voucher INTEGER NOT NULL,
+	invite INTEGER NOT NULL,
+	date TIMESTAMP,
+	ip TEXT,
+	FOREIGN KEY (voucher) REFERENCES Voucher(id_voucher),
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
+);
+
+CREATE TABLE PasswordReset (
+	id_reset INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL,
+	token TEXT NOT NULL,
+	expiration TIMESTAMP,
+	used INTEGER DEFAULT 0,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
 );
 
 CREATE TABLE Administrateur (
 	id_admin INTEGER PRIMARY KEY,
 	login TEXT,
-	mdp TEXT
+	mdp TEXT,
+	role TEXT NOT NULL
+);
+
+CREATE TABLE CheckIn (
+	id_checkin INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL UNIQUE,
+	date TIMESTAMP,
+	staff INTEGER,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite),
+	FOREIGN KEY (staff) REFERENCES Administrateur(id_admin)
 );
 
 CREATE TABLE Session (
@@ -76,6 +185,7 @@ func InitDatabase(db *sql.DB) error {
 
 // CreateAdmin create the admin using a modele and return the inserted id.
 // The password is hashed using the HashPassword func described in database.go.
+// Without role the admin is a real admin (RoleAdmin).
 func CreateAdmin(db *sql.DB, admin *modele.Admin) (int64, error) {
 	var notHashedPsw string = admin.Psw
 	var hashedPsw string
@@ -85,7 +195,11 @@ func CreateAdmin(db *sql.DB, admin *modele.Admin) (int64, error) {
 		return -1, err
 	}
 
-	result, err := db.Exec("INSERT INTO Administrateur(login,mdp) VALUES (?,?)", admin.Login, hashedPsw)
+	if admin.Role == "" {
+		admin.Role = modele.RoleAdmin
+	}
+
+	result, err := db.Exec("INSERT INTO Administrateur(login,mdp,role) VALUES (?,?,?)", admin.Login, hashedPsw, admin.Role)
 
 	if err != nil {
 		return -1, err
@@ -18,7 +18,7 @@ import (
 // You can't use any function in this file without getting the DB object so you need to use this function.
 // Changing the SGBD should only be done here.
 func Connect() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", *config.DbFile) // Connect to the sqlite database
+	db, err := sql.Open("sqlite3", *config.DbFile+"?_loc=auto") // Connect to the sqlite database, dates are read in the server's time zone
 	if err != nil {
 		return nil, err
 	}
@@ -51,26 +51,50 @@ func CheckPasswordHash(password string, hash string) bool {
 
 // CreateUser use a Invite struct from modele to insert the invite into the database.
 // It hash the password provided using HashPassword()
+// If the event is full the invite is put on the waitlist, i.Statut tells which one.
+// Registering means attending, the RSVP answer is set to present.
+// The voucher used to register (redemption.Voucher) is redeemed in the same transaction, "Voucher used up" is returned if his limit is reached.
+// The redemption is recorded with the invite, so the voucher an invite used is always known.
 // Provided informations can be **empty** but **not nil**!!!
-func CreateUser(db *sql.DB, i *modele.Invite) (int64, error) { // Create user, return user id or error
+func CreateUser(db *sql.DB, i *modele.Invite, redemption modele.Redemption) (int64, error) { // Create user, return user id or error
+	hashedPsw, err := HashPassword(i.Mdp) // Hashing the password before sending to database, it is slow so do it before the transaction
+	if err != nil {
+		return -1, err
+	}
+
 	tx, err := db.Begin() // Start transaction
 	if err != nil {
 		return -1, err
 	}
 
 	defer tx.Rollback() // Close transaction no matter what
+
+	// Redeem the voucher first: the write locks the database until commit so two registrations can't take the last use
+	err = redeemVoucher(tx, redemption.Voucher)
+	if err != nil {
+		return -1, err
+	}
+
 	stmt, err :=
-		tx.Prepare("INSERT INTO Invite(id_invite,nom,prenom,mail,mdp,numtel,parrain)" +
-			" VALUES (NULL,?,?,?,?,?,?)") // Insert into Invite
+		tx.Prepare("INSERT INTO Invite(id_invite,nom,prenom,mail,mdp,numtel,parrain,event,statut,max_companions,rsvp,rsvp_date,voucher_quota,quota_expiration,depth)" +
+			" VALUES (NULL,?,?,?,?,?,?,?,?,?,?,?,?,?,?)") // Insert into Invite
 	if err != nil {
 		return -1, err
 	}
 	defer stmt.Close() // Close the statement no matter what
 
-	hashedPsw, err := HashPassword(i.Mdp) // Hashing the password before sending to database
+	// Check capacity in the same transaction so the last place can't be given twice
+	enough, err := hasPlaces(tx, i.Event, 1)
 	if err != nil {
 		return -1, err
 	}
+	if enough {
+		i.Statut = modele.StatutConfirme
+	} else {
+		i.Statut = modele.StatutAttente
+	}
+	i.Rsvp = modele.RsvpPresent
+	i.RsvpDate = time.Now()
 
 	result, err := stmt.Exec( // Fill placeholders
 		i.Nom,
@@ -79,17 +103,54 @@ func CreateUser(db *sql.DB, i *modele.Invite) (int64, error) { // Create user, r
 		hashedPsw, // The password is hashed using bcrypt
 		i.Numtel,
 		i.Parrain,
+		i.Event,
+		i.Statut,
+		i.MaxCompanions,
+		i.Rsvp,
+		i.RsvpDate,
+		i.VoucherQuota,
+		i.QuotaExpiration,
+		i.Depth,
 	)
 	if err != nil {
 		return -1, err
 	}
+	idInvite, err := result.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	// Keep track of the voucher used, a parrain can have several ones
+	redemption.Invite = idInvite
+	err = addRedemption(tx, redemption)
+	if err != nil {
+		return -1, err
+	}
 
 	err = tx.Commit() // Commit changes to database
 	if err != nil {
 		return -1, err
 	}
 
-	return result.LastInsertId() // Return the id of the created user or error
+	return idInvite, nil // Return the id of the created user
+}
+
+// redeemVoucher count one more use of a voucher if his limit isn't reached.
+// The check and the increment are done in a single UPDATE so they can't be separated.
+func redeemVoucher(tx *sql.Tx, idVoucher int64) error {
+	result, err := tx.Exec("UPDATE Voucher SET used = used + 1"+
+		" WHERE id_voucher = ? AND statut = ? AND (max_uses = 0 OR used < max_uses)", idVoucher, modele.VoucherActif)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("Voucher used up") // Nothing updated, the limit is reached (or the voucher was just disabled)
+	}
+	return nil
 }
 
 // UniqueMail tell if the provided email is unique in database or not
@@ -121,10 +182,11 @@ func UniqueMail(db *sql.DB, mail string) (bool, error) {
 	return numOccurences <= 0, nil // Expect 0 if mail is unique
 }
 
-// CheckVoucher check the validity of a voucher. It check existance and expiration time.
+// CheckVoucher check the validity of a voucher. It check existance, status, activation and expiration time and redemption limit.
+// The limit is checked again when the voucher is redeemed, see CreateUser().
 // Return true and nil in case of sucess, return false and specify why in err if failed
 func CheckVoucher(db *sql.DB, code string) (bool, error) {
-	result, err := db.Query("SELECT id_voucher,code,expiration,proprietaire"+
+	result, err := db.Query("SELECT id_voucher,code,expiration,proprietaire,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer"+
 		" FROM Voucher WHERE code = ?", code)
 	if err != nil {
 		return false, err
@@ -139,20 +201,97 @@ func CheckVoucher(db *sql.DB, code string) (bool, error) {
 			&voucher.Code,
 			&voucher.Expiration,
 			&voucher.Prop,
+			&voucher.MaxUses,
+			&voucher.Used,
+			&voucher.Statut,
+			&voucher.NotBefore,
+			&voucher.Auto.MaxUses,
+			&voucher.Auto.Days,
+			&voucher.Auto.MaxDepth,
+			&voucher.Issuer,
 		)
-		if voucher.Expiration.After(time.Now()) {
-			return true, nil
+		if voucher.Disabled() {
+			return false, errors.New("Voucher disabled") // A voucher was found but disabled by an admin
+		}
+		if voucher.NotBefore.After(time.Now()) {
+			return false, errors.New("Voucher not yet valid") // A voucher was found but can't be used yet
+		}
+		if !voucher.Expiration.After(time.Now()) {
+			return false, errors.New("Voucher expired") // A voucher was found but expired
+		}
+		if voucher.UsedUp() {
+			return false, errors.New("Voucher used up") // A voucher was found but can't be used anymore
 		}
-		return false, errors.New("Voucher expired") // A voucher was found but expired
+		return true, nil
 	}
 	return false, errors.New("Voucher doesn't exist") // Nothing was found
 
 }
 
+// GetVoucher return a Voucher modele using its code.
+// It doesn't check validity, use CheckVoucher() for this.
+func GetVoucher(db *sql.DB, code string) (modele.Voucher, error) {
+	var voucher modele.Voucher = modele.Voucher{}
+	result, err := db.Query("SELECT id_voucher,code,expiration,proprietaire,event,max_companions,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer"+
+		" FROM Voucher WHERE code = ?", code)
+	if err != nil {
+		return voucher, err
+	}
+	defer result.Close()
+
+	if result.Next() { // No iteration because voucher should be unique
+		err = result.Scan(
+			&voucher.Id,
+			&voucher.Code,
+			&voucher.Expiration,
+			&voucher.Prop,
+			&voucher.Event,
+			&voucher.MaxCompanions,
+			&voucher.MaxUses,
+			&voucher.Used,
+			&voucher.Statut,
+			&voucher.NotBefore,
+			&voucher.Auto.MaxUses,
+			&voucher.Auto.Days,
+			&voucher.Auto.MaxDepth,
+			&voucher.Issuer,
+		)
+		return voucher, err
+	}
+	return voucher, errors.New("Voucher doesn't exist") // Nothing was found
+}
+
+// GetVoucherById return a Voucher modele using its id_voucher.
+func GetVoucherById(db *sql.DB, idVoucher int64) (modele.Voucher, error) {
+	var voucher modele.Voucher = modele.Voucher{}
+	err := db.QueryRow("SELECT id_voucher,code,expiration,proprietaire,event,max_companions,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer"+
+		" FROM Voucher WHERE id_voucher = ?", idVoucher).Scan(
+		&voucher.Id,
+		&voucher.Code,
+		&voucher.Expiration,
+		&voucher.Prop,
+		&voucher.Event,
+		&voucher.MaxCompanions,
+		&voucher.MaxUses,
+		&voucher.Used,
+		&voucher.Statut,
+		&voucher.NotBefore,
+		&voucher.Auto.MaxUses,
+		&voucher.Auto.Days,
+		&voucher.Auto.MaxDepth,
+		&voucher.Issuer,
+	)
+	if err == sql.ErrNoRows {
+		return voucher, errors.New("Voucher doesn't exist")
+	}
+	return voucher, err
+}
+
 // GetParrain return the parrain id for a voucher and check voucher validity.
 // This function is used to link Invite to his parrain on registration.
 // Doesn't use a modele, should be merged with CreateUser() somehow.
-// Return parrain user id or -1 if voucher is invalid
+// Return "Depth exceeded" if the new invite would be deeper than the maximum depth of the referral tree.
+// Return parrain user id, 0 if the voucher was issued by an admin or -1 if voucher is invalid
 func GetParrain(db *sql.DB, code string) (int64, error) {
 	tx, err := db.Begin() // Start transaction
 	if err != nil {
@@ -161,7 +300,7 @@ func GetParrain(db *sql.DB, code string) (int64, error) {
 
 	defer tx.Rollback() // Close transaction no matter what
 	stmt, err :=
-		tx.Prepare("SELECT id_invite FROM Invite, Voucher" +
+		tx.Prepare("SELECT id_invite,depth FROM Invite, Voucher" +
 			" WHERE proprietaire = id_invite" +
 			" AND code = ?") // Count line with selected email
 	if err != nil {
@@ -174,14 +313,27 @@ func GetParrain(db *sql.DB, code string) (int64, error) {
 	if !voucherValid {
 		return -1, err
 	}
+	voucher, err := GetVoucher(db, code)
+	if err != nil {
+		return -1, err
+	}
+	if voucher.Issuer != 0 { // Root of the referral tree, there's no parrain
+		return 0, nil
+	}
 
 	result, err := stmt.Query(code) // Fill placeholder and execute query
 	defer result.Close()
 
 	if result.Next() { // No iteration because voucher should be unique
-		var idParrain int64
-		err = result.Scan(&idParrain)
-		return idParrain, err
+		var parrain modele.Invite
+		err = result.Scan(&parrain.Id, &parrain.Depth)
+		if err != nil {
+			return -1, err
+		}
+		if !parrain.CanSponsor(*config.MaxDepth) {
+			return -1, errors.New("Depth exceeded")
+		}
+		return parrain.Id, nil
 	}
 	return -1, nil // No error but id parrain is -1 mean nothing found
 }
@@ -219,6 +371,14 @@ func ConnectUser(db *sql.DB, i *modele.Invite) error { // Receive result in i by
 			&hashedPsw, // Getting hashed password from database
 			&i.Numtel,
 			&i.Parrain,
+			&i.Event,
+			&i.Statut,
+			&i.MaxCompanions,
+			&i.Rsvp,
+			&i.RsvpDate,
+			&i.VoucherQuota,
+			&i.QuotaExpiration,
+			&i.Depth,
 		)
 
 		// Check password
@@ -238,7 +398,7 @@ func ConnectUser(db *sql.DB, i *modele.Invite) error { // Receive result in i by
 // It should still work very fast if the number of registration is < 200
 // Info from database can be **empty** but **can't be nil**!!
 func ListInvite(db *sql.DB, listI *[]modele.Invite) error {
-	result, err := db.Query("SELECT id_invite,nom,prenom,mail,numtel,parrain" +
+	result, err := db.Query("SELECT id_invite,nom,prenom,mail,numtel,parrain,event,statut,max_companions,rsvp,rsvp_date,voucher_quota,quota_expiration,depth" +
 		" FROM Invite ORDER BY nom")
 	if err != nil {
 		return err
@@ -256,6 +416,14 @@ func ListInvite(db *sql.DB, listI *[]modele.Invite) error {
 			&inviteTmp.Mail,
 			&inviteTmp.Numtel,
 			&inviteTmp.Parrain,
+			&inviteTmp.Event,
+			&inviteTmp.Statut,
+			&inviteTmp.MaxCompanions,
+			&inviteTmp.Rsvp,
+			&inviteTmp.RsvpDate,
+			&inviteTmp.VoucherQuota,
+			&inviteTmp.QuotaExpiration,
+			&inviteTmp.Depth,
 		)
 		if err != nil { // If something goes wrong during iteration don't screw up everything, keep going and keep errors for later
 			errL += err.Error() // Handle multiple errors
@@ -269,14 +437,15 @@ func ListInvite(db *sql.DB, listI *[]modele.Invite) error {
 	return err
 }
 
-// GetVouchers extract all vouchers from database in an HashMap associating userId with voucher code.
+// GetVouchers extract all vouchers from database in an HashMap associating userId with his vouchers.
+// An invite can have several vouchers, they're in the order they were created.
 // TODO This should be improve with paging to avoid crash/lag/slowing/instability with heavy database.
 // This isn't much of an issue because hashmap is fast. Needs testing.
 // Info from database can be **empty** but **can't be nil**!!
-func GetVouchers(db *sql.DB, vouchers map[int64]modele.Voucher) error {
-	result, err := db.Query("SELECT id_invite,id_voucher,code,expiration,proprietaire" +
+func GetVouchers(db *sql.DB, vouchers map[int64][]modele.Voucher) error {
+	result, err := db.Query("SELECT id_invite,id_voucher,code,expiration,proprietaire,Voucher.event,Voucher.max_companions,max_uses,used,Voucher.statut,not_before,auto_uses,auto_days,auto_depth,issuer" +
 		" FROM Voucher,Invite" +
-		" WHERE id_invite = proprietaire")
+		" WHERE id_invite = proprietaire ORDER BY id_voucher")
 	if err != nil {
 		return err
 	}
@@ -292,8 +461,18 @@ func GetVouchers(db *sql.DB, vouchers map[int64]modele.Voucher) error {
 			&voucherTmp.Code,
 			&voucherTmp.Expiration,
 			&voucherTmp.Prop,
+			&voucherTmp.Event,
+			&voucherTmp.MaxCompanions,
+			&voucherTmp.MaxUses,
+			&voucherTmp.Used,
+			&voucherTmp.Statut,
+			&voucherTmp.NotBefore,
+			&voucherTmp.Auto.MaxUses,
+			&voucherTmp.Auto.Days,
+			&voucherTmp.Auto.MaxDepth,
+			&voucherTmp.Issuer,
 		)
-		vouchers[id] = voucherTmp // Build the map with every vouchers, associate with id_invite
+		vouchers[id] = append(vouchers[id], voucherTmp) // Build the map with every vouchers, associate with id_invite
 		if err != nil {
 			errL += err.Error() // Handle multiple errors
 		}
@@ -304,21 +483,89 @@ func GetVouchers(db *sql.DB, vouchers map[int64]modele.Voucher) error {
 	return err
 }
 
+// ListVouchers fill the slice with the vouchers owned by an invite, in the order they were created.
+// Info from database can be **empty** but **can't be nil**!!
+func ListVouchers(db *sql.DB, idInvite int64, listV *[]modele.Voucher) error {
+	result, err := db.Query("SELECT id_voucher,code,expiration,proprietaire,event,max_companions,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer"+
+		" FROM Voucher WHERE proprietaire = ? ORDER BY id_voucher", idInvite)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var voucherTmp modele.Voucher
+		err = result.Scan(
+			&voucherTmp.Id,
+			&voucherTmp.Code,
+			&voucherTmp.Expiration,
+			&voucherTmp.Prop,
+			&voucherTmp.Event,
+			&voucherTmp.MaxCompanions,
+			&voucherTmp.MaxUses,
+			&voucherTmp.Used,
+			&voucherTmp.Statut,
+			&voucherTmp.NotBefore,
+			&voucherTmp.Auto.MaxUses,
+			&voucherTmp.Auto.Days,
+			&voucherTmp.Auto.MaxDepth,
+			&voucherTmp.Issuer,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+
+		*listV = append(*listV, voucherTmp)
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
+
 // AddVoucher add a voucher in database using a modele.
 // Values can be empty but can't be nil or it will troublesome when getting them.
+// Return "Code already used" if another voucher has the same code.
 func AddVoucher(db *sql.DB, voucher modele.Voucher) error {
-	_, err := db.Exec("INSERT INTO Voucher(code,expiration,proprietaire)"+
-		" VALUES (?,?,?)",
-		voucher.Code, voucher.Expiration, voucher.Prop)
+	_, err := GetVoucher(db, voucher.Code)
+	if err == nil {
+		return errors.New("Code already used")
+	}
+	if err.Error() != "Voucher doesn't exist" {
+		return err
+	}
+
+	_, err = db.Exec("INSERT INTO Voucher(code,expiration,proprietaire,event,max_companions,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer)"+
+		" VALUES (?,?,?,?,?,?,0,?,?,?,?,?,?)",
+		voucher.Code, voucher.Expiration, voucher.Prop, voucher.Event, voucher.MaxCompanions, voucher.MaxUses, modele.VoucherActif, voucher.NotBefore,
+		voucher.Auto.MaxUses, voucher.Auto.Days, voucher.Auto.MaxDepth, voucher.Issuer)
 	return err
 }
 
-// DisableVoucher disable a voucher in database using a userId.
-// Disable a voucher means set his expiration date to Thu Jan 01 00:00:00 1970 UTC (UNIX time 0)
-func DisableVoucher(db *sql.DB, idInvite int64) error {
-	disableTime := time.Unix(0, 0) // Disable a voucher means set his expiration date to Thu Jan 01 00:00:00 1970 UTC
+// DisableVoucher disable a voucher in database using its id_voucher.
+// The expiration date is kept so the voucher can be enabled again with UpdateVoucher().
+// The other vouchers of the owner aren't changed.
+func DisableVoucher(db *sql.DB, idVoucher int64) error {
+	_, err := db.Exec("UPDATE Voucher SET statut = ? WHERE id_voucher = ?", modele.VoucherDesactive, idVoucher)
+	return err
+}
+
+// UpdateVoucher change the code, activation and expiration dates, redemption limit, automatic voucher policy and status of a voucher using its id_voucher.
+// Return "Code already used" if another voucher has the same code.
+func UpdateVoucher(db *sql.DB, voucher modele.Voucher) error {
+	existing, err := GetVoucher(db, voucher.Code)
+	if err == nil && existing.Id != voucher.Id {
+		return errors.New("Code already used")
+	}
+	if err != nil && err.Error() != "Voucher doesn't exist" {
+		return err
+	}
 
-	_, err := db.Exec("UPDATE Voucher SET expiration = ? WHERE proprietaire = ?", disableTime, idInvite)
+	_, err = db.Exec("UPDATE Voucher SET code = ?, expiration = ?, max_uses = ?, statut = ?, not_before = ?,"+
+		" auto_uses = ?, auto_days = ?, auto_depth = ? WHERE id_voucher = ?",
+		voucher.Code, voucher.Expiration, voucher.MaxUses, voucher.Statut, voucher.NotBefore,
+		voucher.Auto.MaxUses, voucher.Auto.Days, voucher.Auto.MaxDepth, voucher.Id)
 	return err
 }
 
@@ -327,7 +574,7 @@ func DisableVoucher(db *sql.DB, idInvite int64) error {
 // Improvement: could be merge with ListInvite() since they're quiet similar.
 func GetInvite(db *sql.DB, id_invite int64) (modele.Invite, error) {
 	var invite modele.Invite = modele.Invite{}
-	result, err := db.Query("SELECT id_invite,nom,prenom,mail,numtel,parrain"+
+	result, err := db.Query("SELECT id_invite,nom,prenom,mail,numtel,parrain,event,statut,max_companions,rsvp,rsvp_date,voucher_quota,quota_expiration,depth"+
 		" FROM Invite WHERE id_invite = ?", id_invite)
 	if err != nil {
 		return invite, err
@@ -341,6 +588,14 @@ func GetInvite(db *sql.DB, id_invite int64) (modele.Invite, error) {
 		&invite.Mail,
 		&invite.Numtel,
 		&invite.Parrain,
+		&invite.Event,
+		&invite.Statut,
+		&invite.MaxCompanions,
+		&invite.Rsvp,
+		&invite.RsvpDate,
+		&invite.VoucherQuota,
+		&invite.QuotaExpiration,
+		&invite.Depth,
 	)
 	return invite, err
 }
@@ -350,7 +605,7 @@ func ConnectAdmin(db *sql.DB, admin *modele.Admin) error {
 	var notHashedPsw string = admin.Psw
 	var hashedPsw string
 
-	result, err := db.Query("SELECT id_admin,login,mdp FROM Administrateur WHERE login = ?", admin.Login)
+	result, err := db.Query("SELECT id_admin,login,mdp,role FROM Administrateur WHERE login = ?", admin.Login)
 	defer result.Close()
 
 	if !result.Next() {
@@ -361,6 +616,7 @@ func ConnectAdmin(db *sql.DB, admin *modele.Admin) error {
 		&admin.IdAdmin,
 		&admin.Login,
 		&hashedPsw,
+		&admin.Role,
 	)
 	// Check password
 	if CheckPasswordHash(notHashedPsw, hashedPsw) {
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// AddEvent insert an event in database using a modele and return the inserted id.
+// Values can be empty but can't be nil.
+func AddEvent(db *sql.DB, event modele.Event) (int64, error) {
+	result, err := db.Exec("INSERT INTO Event(nom,description,lieu,debut,fin,capacite,max_companions,auto_uses,auto_days,auto_depth)"+
+		" VALUES (?,?,?,?,?,?,?,?,?,?)",
+		event.Nom, event.Description, event.Lieu, event.Debut, event.Fin, event.Capacite, event.MaxCompanions,
+		event.Auto.MaxUses, event.Auto.Days, event.Auto.MaxDepth)
+	if err != nil {
+		return -1, err
+	}
+	return result.LastInsertId()
+}
+
+// GetEvent return an Event modele using an id_event.
+// Return an error if the event doesn't exist.
+func GetEvent(db *sql.DB, idEvent int64) (modele.Event, error) {
+	var event modele.Event = modele.Event{}
+	result, err := db.Query("SELECT id_event,nom,description,lieu,debut,fin,capacite,max_companions,auto_uses,auto_days,auto_depth"+
+		" FROM Event WHERE id_event = ?", idEvent)
+	if err != nil {
+		return event, err
+	}
+	defer result.Close()
+
+	if result.Next() { // No iteration because id is unique
+		err = result.Scan( // Fill event
+			&event.Id,
+			&event.Nom,
+			&event.Description,
+			&event.Lieu,
+			&event.Debut,
+			&event.Fin,
+			&event.Capacite,
+			&event.MaxCompanions,
+			&event.Auto.MaxUses,
+			&event.Auto.Days,
+			&event.Auto.MaxDepth,
+		)
+		return event, err
+	}
+	return event, errors.New("Event doesn't exist")
+}
+
+// ListEvents fill the slice with every event in database ordered by date.
+// Info from database can be **empty** but **can't be nil**!!
+func ListEvents(db *sql.DB, listE *[]modele.Event) error {
+	result, err := db.Query("SELECT id_event,nom,description,lieu,debut,fin,capacite,max_companions,auto_uses,auto_days,auto_depth" +
+		" FROM Event ORDER BY debut")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var eventTmp modele.Event
+		err = result.Scan(
+			&eventTmp.Id,
+			&eventTmp.Nom,
+			&eventTmp.Description,
+			&eventTmp.Lieu,
+			&eventTmp.Debut,
+			&eventTmp.Fin,
+			&eventTmp.Capacite,
+			&eventTmp.MaxCompanions,
+			&eventTmp.Auto.MaxUses,
+			&eventTmp.Auto.Days,
+			&eventTmp.Auto.MaxDepth,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+
+		*listE = append(*listE, eventTmp)
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
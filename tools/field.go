@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// AddField insert a registration field in database using a modele.
+func AddField(db *sql.DB, field modele.Field) error {
+	_, err := db.Exec("INSERT INTO Field(event,libelle,type,options,obligatoire) VALUES (?,?,?,?,?)",
+		field.Event, field.Libelle, field.Type, field.Options, field.Obligatoire)
+	return err
+}
+
+// DeleteField delete a registration field and the answers given to it.
+func DeleteField(db *sql.DB, idField int64) error {
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	_, err = tx.Exec("DELETE FROM Answer WHERE field = ?", idField)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM Field WHERE id_field = ?", idField)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListFields fill the slice with the registration fields of an event, in creation order.
+// Info from database can be **empty** but **can't be nil**!!
+func ListFields(db *sql.DB, idEvent int64, listF *[]modele.Field) error {
+	result, err := db.Query("SELECT id_field,event,libelle,type,options,obligatoire"+
+		" FROM Field WHERE event = ? ORDER BY id_field", idEvent)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var fieldTmp modele.Field
+		err = result.Scan(
+			&fieldTmp.Id,
+			&fieldTmp.Event,
+			&fieldTmp.Libelle,
+			&fieldTmp.Type,
+			&fieldTmp.Options,
+			&fieldTmp.Obligatoire,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+
+		*listF = append(*listF, fieldTmp)
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
+
+// SaveAnswers insert the answers of an invite to the registration fields.
+func SaveAnswers(db *sql.DB, idInvite int64, answers []modele.Answer) error {
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	for _, answer := range answers {
+		_, err = tx.Exec("INSERT INTO Answer(field,invite,valeur) VALUES (?,?,?)", answer.Field, idInvite, answer.Valeur)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAnswers extract all answers from database in an HashMap associating userId with his answers.
+// Answers are in the fields order.
+// Info from database can be **empty** but **can't be nil**!!
+func GetAnswers(db *sql.DB, answers map[int64][]modele.Answer) error {
+	result, err := db.Query("SELECT field,invite,libelle,valeur" +
+		" FROM Answer,Field" +
+		" WHERE field = id_field ORDER BY id_field")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var answerTmp modele.Answer
+		err = result.Scan(
+			&answerTmp.Field,
+			&answerTmp.Invite,
+			&answerTmp.Libelle,
+			&answerTmp.Valeur,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+		answers[answerTmp.Invite] = append(answers[answerTmp.Invite], answerTmp) // Associate with id_invite
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// ListAdminVouchers fill the slice with the vouchers issued by admins, in the order they were created.
+// Info from database can be **empty** but **can't be nil**!!
+func ListAdminVouchers(db *sql.DB, listV *[]modele.Voucher) error {
+	result, err := db.Query("SELECT id_voucher,code,expiration,proprietaire,event,max_companions,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer" +
+		" FROM Voucher WHERE issuer > 0 ORDER BY id_voucher")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var voucherTmp modele.Voucher
+		err = result.Scan(
+			&voucherTmp.Id,
+			&voucherTmp.Code,
+			&voucherTmp.Expiration,
+			&voucherTmp.Prop,
+			&voucherTmp.Event,
+			&voucherTmp.MaxCompanions,
+			&voucherTmp.MaxUses,
+			&voucherTmp.Used,
+			&voucherTmp.Statut,
+			&voucherTmp.NotBefore,
+			&voucherTmp.Auto.MaxUses,
+			&voucherTmp.Auto.Days,
+			&voucherTmp.Auto.MaxDepth,
+			&voucherTmp.Issuer,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+
+		*listV = append(*listV, voucherTmp)
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
+
+// GetAdminLogins extract the login of every admin and staff account in an HashMap associating id_admin with the login.
+// Info from database can be **empty** but **can't be nil**!!
+func GetAdminLogins(db *sql.DB, logins map[int64]string) error {
+	result, err := db.Query("SELECT id_admin,login FROM Administrateur")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var id int64
+		var login string
+		err = result.Scan(&id, &login)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+		logins[id] = login
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"regexp"
+
+	"github.com/DucNg/resa/config"
+)
+
+// Mailer send an email to a single address. Use NewMailer() to get the one given by the configuration.
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// SMTPMailer send emails through an SMTP server (host:port).
+// The authentication is only used when a user is given, so a local SMTP server (MailHog, smtp4dev...) can be used for tests.
+type SMTPMailer struct {
+	Server   string
+	User     string
+	Password string
+	From     string
+}
+
+// Send the email as plain text in UTF-8.
+func (m SMTPMailer) Send(to string, subject string, body string) error {
+	var auth smtp.Auth
+	if m.User != "" {
+		host, _, err := net.SplitHostPort(m.Server)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.User, m.Password, host)
+	}
+
+	msg := "From: " + m.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + body
+	return smtp.SendMail(m.Server, auth, m.From, []string{to}, []byte(msg))
+}
+
+// LogMailer write emails in the log instead of sending them. It's used when no SMTP server is configured.
+type LogMailer struct{}
+
+// tokenParam find the tokens given in the links of an email.
+var tokenParam = regexp.MustCompile(`token=[^\s&]+`)
+
+// Send write the email in the log. Tokens are masked, anyone reading the log could use them otherwise,
+// so the links of the email can't be used: a warning tells the mail wasn't sent.
+func (LogMailer) Send(to string, subject string, body string) error {
+	log.Println("No SMTP server given with -smtp, mail to " + to + " not sent: " + subject + "\n" + tokenParam.ReplaceAllString(body, "token=REDACTED"))
+	return nil
+}
+
+// NewMailer return the mailer given by the configuration: SMTP if a server is set, the log otherwise.
+func NewMailer() Mailer {
+	if *config.SMTPServer == "" {
+		return LogMailer{}
+	}
+	return SMTPMailer{*config.SMTPServer, *config.SMTPUser, *config.SMTPPassword, *config.MailFrom}
+}
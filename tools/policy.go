@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"time"
+
+	"github.com/DucNg/resa/config"
+	"github.com/DucNg/resa/modele"
+)
+
+// IssueVoucher create the voucher given automatically to an invite on registration.
+// The invite must already be in database with his depth.
+// Nothing is created if the policy doesn't allow it or if the invite is at the maximum depth of the referral tree.
+// The voucher is for the invite's event, it follows the policy and carries the same policy (auto) so the chain goes on.
+// Return the generated code, or an empty string if no voucher was created.
+func IssueVoucher(db *sql.DB, invite modele.Invite, event modele.Event, policy modele.Policy, auto modele.Policy) (string, error) {
+	if !policy.Allows(invite.Depth) || !invite.CanSponsor(*config.MaxDepth) {
+		return "", nil
+	}
+
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	code, err := uniqueCode(tx)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec("INSERT INTO Voucher(code,expiration,proprietaire,event,max_companions,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer)"+
+		" VALUES (?,?,?,?,?,?,0,?,?,?,?,?,0)",
+		code, policy.Expiration(event), invite.Id, invite.Event, -1, policy.MaxUses, modele.VoucherActif, time.Time{},
+		auto.MaxUses, auto.Days, auto.MaxDepth)
+	if err != nil {
+		return "", err
+	}
+
+	return code, tx.Commit()
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+	"time"
+
+	"github.com/DucNg/resa/config"
+	"github.com/DucNg/resa/modele"
+)
+
+// SetQuota set the number of vouchers an invite can create himself and the latest expiration date of these vouchers.
+func SetQuota(db *sql.DB, idInvite int64, quota int, expiration time.Time) error {
+	_, err := db.Exec("UPDATE Invite SET voucher_quota = ?, quota_expiration = ? WHERE id_invite = ?",
+		quota, expiration, idInvite)
+	return err
+}
+
+// CreateGuestVoucher create a voucher owned by an invite, within his quota.
+// The voucher is for the invite's event and can be used once, the code is generated.
+// Return "No voucher left" if the quota is used and "Invalid expiration" if the expiration is past or after the maximum given by the admin.
+// Return "Depth exceeded" if the invite is at the maximum depth of the referral tree.
+func CreateGuestVoucher(db *sql.DB, invite modele.Invite, expiration time.Time) (string, error) {
+	if !invite.CanSponsor(*config.MaxDepth) {
+		return "", errors.New("Depth exceeded")
+	}
+	if !expiration.After(time.Now()) || expiration.After(invite.QuotaExpiration) {
+		return "", errors.New("Invalid expiration")
+	}
+
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	// Take one from the quota first, in the same UPDATE as the check so it can't go below 0
+	result, err := tx.Exec("UPDATE Invite SET voucher_quota = voucher_quota - 1"+
+		" WHERE id_invite = ? AND voucher_quota > 0", invite.Id)
+	if err != nil {
+		return "", err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", errors.New("No voucher left")
+	}
+
+	code, err := uniqueCode(tx)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec("INSERT INTO Voucher(code,expiration,proprietaire,event,max_companions,max_uses,used,statut,not_before,auto_uses,auto_days,auto_depth,issuer)"+
+		" VALUES (?,?,?,?,?,?,0,?,?,-1,0,0,0)",
+		code, expiration, invite.Id, invite.Event, -1, 1, modele.VoucherActif, time.Time{})
+	if err != nil {
+		return "", err
+	}
+
+	return code, tx.Commit()
+}
+
+// RevokeVoucher disable a voucher of an invite. The quota isn't given back.
+// Return "Voucher doesn't exist" if the invite doesn't own the voucher.
+func RevokeVoucher(db *sql.DB, idVoucher int64, idInvite int64) error {
+	result, err := db.Exec("UPDATE Voucher SET statut = ? WHERE id_voucher = ? AND proprietaire = ?",
+		modele.VoucherDesactive, idVoucher, idInvite)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("Voucher doesn't exist")
+	}
+	return nil
+}
+
+// uniqueCode generate codes until one isn't used by another voucher yet.
+func uniqueCode(tx *sql.Tx) (string, error) {
+	for {
+		code, err := GenerateCode(*config.CodePrefix, *config.CodeLength)
+		if err != nil {
+			return "", err
+		}
+		var count int
+		err = tx.QueryRow("SELECT COUNT(*) FROM Voucher WHERE code = ?", code).Scan(&count)
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+}
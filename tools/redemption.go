@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// addRedemption record that an invite has registered with a voucher, in the transaction creating the invite.
+// Values can be empty but can't be nil.
+func addRedemption(tx *sql.Tx, redemption modele.Redemption) error {
+	_, err := tx.Exec("INSERT INTO Redemption(voucher,invite,date,ip) VALUES (?,?,?,?)",
+		redemption.Voucher, redemption.Invite, redemption.Date, redemption.IP)
+	return err
+}
+
+// ListRedemptions fill the slice with every use of a voucher, in the order they happened.
+// Info from database can be **empty** but **can't be nil**!!
+func ListRedemptions(db *sql.DB, idVoucher int64, listR *[]modele.Redemption) error {
+	result, err := db.Query("SELECT id_redemption,voucher,invite,date,ip,nom,prenom,mail"+
+		" FROM Redemption,Invite"+
+		" WHERE invite = id_invite AND voucher = ? ORDER BY date", idVoucher)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var redemptionTmp modele.Redemption
+		err = result.Scan(
+			&redemptionTmp.Id,
+			&redemptionTmp.Voucher,
+			&redemptionTmp.Invite,
+			&redemptionTmp.Date,
+			&redemptionTmp.IP,
+			&redemptionTmp.Nom,
+			&redemptionTmp.Prenom,
+			&redemptionTmp.Mail,
+		)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+
+		*listR = append(*listR, redemptionTmp)
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
+
+// ListReferrals fill the slice with the invites sponsored by an invite and the voucher each one used.
+// Only the first name and the initial of the name are given, see modele.NewReferral().
+func ListReferrals(db *sql.DB, idParrain int64, listR *[]modele.Referral) error {
+	result, err := db.Query("SELECT prenom,nom,COALESCE(code,''),Redemption.date"+
+		" FROM Invite"+
+		" LEFT JOIN Redemption ON Redemption.invite = id_invite"+
+		" LEFT JOIN Voucher ON Redemption.voucher = id_voucher"+
+		" WHERE parrain = ? ORDER BY id_invite", idParrain)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var prenom, nom, code string
+		var date sql.NullTime // Invites registered before redemptions were recorded have no date
+		err = result.Scan(&prenom, &nom, &code, &date)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+
+		*listR = append(*listR, modele.NewReferral(prenom, nom, code, date.Time))
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
+
+// GetRedemptions extract all redemptions from database in an HashMap associating the invite with the voucher he used.
+// Info from database can be **empty** but **can't be nil**!!
+func GetRedemptions(db *sql.DB, redemptions map[int64]int64) error {
+	result, err := db.Query("SELECT invite,voucher FROM Redemption")
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	var errL string // Could have multiple errors
+	for result.Next() {
+		var idInvite, idVoucher int64
+		err = result.Scan(&idInvite, &idVoucher)
+		if err != nil {
+			errL += err.Error() // Handle multiple errors
+		}
+		redemptions[idInvite] = idVoucher
+	}
+	if errL != "" {
+		return errors.New(errL) // get any error encountered during iteration
+	}
+	return err
+}
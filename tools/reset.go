@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+	"time"
+
+	"github.com/DucNg/resa/config"
+	"github.com/DucNg/resa/modele"
+)
+
+// hashToken return the SHA-256 of a reset token. The token is random enough so it doesn't need bcrypt.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateResetToken create a single use token to reset the password of the invite using this email.
+// Only the hash of the token is stored, the token itself is only sent by mail.
+// It expires after config.ResetValidity minutes.
+// Return an empty token and no error if no invite uses this email, the user mustn't be told.
+func CreateResetToken(db *sql.DB, mail string) (modele.Invite, string, error) {
+	var invite modele.Invite
+	err := db.QueryRow("SELECT id_invite,nom,prenom,mail FROM Invite WHERE mail = ?", mail).Scan(
+		&invite.Id,
+		&invite.Nom,
+		&invite.Prenom,
+		&invite.Mail,
+	)
+	if err == sql.ErrNoRows {
+		return invite, "", nil
+	}
+	if err != nil {
+		return invite, "", err
+	}
+
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		return invite, "", errors.New("Error generating random")
+	}
+	token := base64.RawURLEncoding.EncodeToString(b) // Safe to put in a link
+
+	expiration := time.Now().Add(time.Duration(*config.ResetValidity) * time.Minute)
+	_, err = db.Exec("INSERT INTO PasswordReset(invite,token,expiration,used) VALUES (?,?,?,0)",
+		invite.Id, hashToken(token), expiration)
+	if err != nil {
+		return invite, "", err
+	}
+	return invite, token, nil
+}
+
+// CheckResetToken tell if a reset token can still be used.
+// Return "Invalid token" if it doesn't exist, has expired or was already used.
+func CheckResetToken(db *sql.DB, token string) error {
+	var idInvite int64
+	var expiration time.Time
+	err := db.QueryRow("SELECT invite,expiration FROM PasswordReset WHERE token = ? AND used = 0",
+		hashToken(token)).Scan(&idInvite, &expiration)
+	if err == sql.ErrNoRows || (err == nil && !expiration.After(time.Now())) {
+		return errors.New("Invalid token")
+	}
+	return err
+}
+
+// ResetPassword change the password of an invite using a reset token.
+// The token and every other token of the invite can't be used anymore, his sessions are closed.
+// Return "Invalid token" if it doesn't exist, has expired or was already used.
+func ResetPassword(db *sql.DB, token string, password string) error {
+	hashedPsw, err := HashPassword(password) // It is slow so do it before the transaction
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	var idInvite int64
+	var expiration time.Time
+	err = tx.QueryRow("SELECT invite,expiration FROM PasswordReset WHERE token = ? AND used = 0",
+		hashToken(token)).Scan(&idInvite, &expiration)
+	if err == sql.ErrNoRows || (err == nil && !expiration.After(time.Now())) {
+		return errors.New("Invalid token")
+	}
+	if err != nil {
+		return err
+	}
+
+	// Use the token in the same UPDATE as the check so it can't be used twice
+	result, err := tx.Exec("UPDATE PasswordReset SET used = 1 WHERE token = ? AND used = 0", hashToken(token))
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("Invalid token")
+	}
+
+	_, err = tx.Exec("UPDATE PasswordReset SET used = 1 WHERE invite = ?", idInvite) // Older links are useless now
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE Invite SET mdp = ? WHERE id_invite = ?", hashedPsw, idInvite)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM Session WHERE id_user = ?", idInvite) // Someone else may be connected with the old password
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// GetDownstream return every invite who registered with a voucher and every invite they have sponsored, directly or not.
+// It uses the redemptions of the voucher and then Invite.Parrain.
+func GetDownstream(db *sql.DB, idVoucher int64) ([]modele.Downstream, error) {
+	var redemptions []modele.Redemption
+	err := ListRedemptions(db, idVoucher, &redemptions)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, redemption := range redemptions {
+		ids = append(ids, redemption.Invite)
+	}
+
+	var listInvite []modele.Invite
+	err = ListInvite(db, &listInvite)
+	if err != nil {
+		return nil, err
+	}
+
+	return modele.FindDownstream(listInvite, ids), nil
+}
+
+// RevokeChain disable a voucher and optionally everything that comes from it:
+// * disableVouchers: the vouchers owned by the downstream invites are disabled too
+// * revokeInvites: the downstream invites are revoked, their places are given to the waitlist
+// Everything is done in one transaction. Return the number of downstream invites.
+func RevokeChain(db *sql.DB, idVoucher int64, disableVouchers bool, revokeInvites bool) (int, error) {
+	downstream, err := GetDownstream(db, idVoucher)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	_, err = tx.Exec("UPDATE Voucher SET statut = ? WHERE id_voucher = ?", modele.VoucherDesactive, idVoucher)
+	if err != nil {
+		return 0, err
+	}
+
+	events := make(map[int64]bool) // Events where places were freed
+	for _, element := range downstream {
+		if disableVouchers {
+			_, err = tx.Exec("UPDATE Voucher SET statut = ? WHERE proprietaire = ?", modele.VoucherDesactive, element.I.Id)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if revokeInvites && !element.I.Termine() {
+			_, err = tx.Exec("UPDATE Invite SET statut = ? WHERE id_invite = ?", modele.StatutRevoque, element.I.Id)
+			if err != nil {
+				return 0, err
+			}
+			events[element.I.Event] = true
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	for idEvent := range events {
+		err = PromoteWaitlist(db, idEvent)
+		if err != nil {
+			return len(downstream), err
+		}
+	}
+	return len(downstream), nil
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+	"time"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// SetRsvp change the RSVP answer of an invite.
+// Every change is kept in the Rsvp table with his date.
+func SetRsvp(db *sql.DB, idInvite int64, rsvp string) error {
+	if !modele.ValidRsvp(rsvp) {
+		return errors.New("Invalid RSVP")
+	}
+
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	now := time.Now()
+	_, err = tx.Exec("UPDATE Invite SET rsvp = ?, rsvp_date = ? WHERE id_invite = ?", rsvp, now, idInvite)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO Rsvp(invite,etat,date) VALUES (?,?,?)", idInvite, rsvp, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
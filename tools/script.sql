@@ -1,7 +1,16 @@
 DROP TABLE Voucher;
 DROP TABLE Session;
+DROP TABLE AdminSession;
 DROP TABLE Invite;
 DROP TABLE Administrateur;
+DROP TABLE Event;
+DROP TABLE CheckIn;
+DROP TABLE Companion;
+DROP TABLE Rsvp;
+DROP TABLE Field;
+DROP TABLE Answer;
+DROP TABLE Redemption;
+DROP TABLE PasswordReset;
 
 CREATE TABLE Invite (
 	id_invite INTEGER PRIMARY KEY,
@@ -10,7 +19,34 @@ CREATE TABLE Invite (
 	mail TEXT NOT NULL,
 	mdp TEXT NOT NULL,
 	numtel TEXT,
-	parrain INTEGER REFERENCES id_invite
+	parrain INTEGER REFERENCES id_invite,
+	event INTEGER,
+	statut TEXT NOT NULL,
+	max_companions INTEGER,
+	rsvp TEXT NOT NULL,
+	rsvp_date TIMESTAMP,
+	voucher_quota INTEGER DEFAULT 0,
+	quota_expiration TIMESTAMP,
+	depth INTEGER DEFAULT 0,
+	FOREIGN KEY (event) REFERENCES Event(id_event)
+);
+
+CREATE TABLE Rsvp (
+	id_rsvp INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL,
+	etat TEXT NOT NULL,
+	date TIMESTAMP,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
+);
+
+CREATE TABLE Companion (
+	id_companion INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL,
+	nom TEXT,
+	prenom TEXT,
+	mail TEXT,
+	numtel TEXT,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
 );
 
 CREATE TABLE Voucher (
@@ -18,13 +54,87 @@ CREATE TABLE Voucher (
 	code TEXT,
 	expiration TIMESTAMP,
 	proprietaire INTEGER,
-	FOREIGN KEY (proprietaire) REFERENCES Invite(id_invite)
+	event INTEGER,
+	max_companions INTEGER,
+	max_uses INTEGER DEFAULT 0,
+	used INTEGER DEFAULT 0,
+	statut TEXT DEFAULT 'actif',
+	not_before TIMESTAMP,
+	auto_uses INTEGER DEFAULT -1,
+	auto_days INTEGER DEFAULT 0,
+	auto_depth INTEGER DEFAULT 0,
+	issuer INTEGER DEFAULT 0,
+	FOREIGN KEY (proprietaire) REFERENCES Invite(id_invite),
+	FOREIGN KEY (issuer) REFERENCES Administrateur(id_admin),
+	FOREIGN KEY (event) REFERENCES Event(id_event)
+);
+
+CREATE TABLE Event (
+	id_event INTEGER PRIMARY KEY,
+	nom TEXT NOT NULL,
+	description TEXT,
+	lieu TEXT,
+	debut TIMESTAMP,
+	fin TIMESTAMP,
+	capacite INTEGER,
+	max_companions INTEGER,
+	auto_uses INTEGER DEFAULT 0,
+	auto_days INTEGER DEFAULT 0,
+	auto_depth INTEGER DEFAULT 0
+);
+
+CREATE TABLE Field (
+	id_field INTEGER PRIMARY KEY,
+	event INTEGER NOT NULL,
+	libelle TEXT NOT NULL,
+	type TEXT NOT NULL,
+	options TEXT,
+	obligatoire BOOLEAN,
+	FOREIGN KEY (event) REFERENCES Event(id_event)
+);
+
+CREATE TABLE Answer (
+	field INTEGER NOT NULL,
+	invite INTEGER NOT NULL,
+	valeur TEXT,
+	PRIMARY KEY (field, invite),
+	FOREIGN KEY (field) REFERENCES Field(id_field),
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
+);
+
+CREATE TABLE Redemption (
+	id_redemption INTEGER PRIMARY KEY,
+	voucher INTEGER NOT NULL,
+	invite INTEGER NOT NULL,
+	date TIMESTAMP,
+	ip TEXT,
+	FOREIGN KEY (voucher) REFERENCES Voucher(id_voucher),
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
+);
+
+CREATE TABLE PasswordReset (
+	id_reset INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL,
+	token TEXT NOT NULL,
+	expiration TIMESTAMP,
+	used INTEGER DEFAULT 0,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite)
 );
 
 CREATE TABLE Administrateur (
 	id_admin INTEGER PRIMARY KEY,
 	login TEXT,
-	mdp TEXT
+	mdp TEXT,
+	role TEXT NOT NULL
+);
+
+CREATE TABLE CheckIn (
+	id_checkin INTEGER PRIMARY KEY,
+	invite INTEGER NOT NULL UNIQUE,
+	date TIMESTAMP,
+	staff INTEGER,
+	FOREIGN KEY (invite) REFERENCES Invite(id_invite),
+	FOREIGN KEY (staff) REFERENCES Administrateur(id_admin)
 );
 
 CREATE TABLE Session (
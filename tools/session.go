@@ -48,7 +48,7 @@ func CreateSession(db *sql.DB, idUser int64) (string, error) {
 func VerifySession(db *sql.DB, token string) (modele.Invite, error) {
 	var i modele.Invite
 
-	result, err := db.Query("SELECT id_invite,nom,prenom,mail,mdp,numtel,parrain"+
+	result, err := db.Query("SELECT id_invite,nom,prenom,mail,mdp,numtel,parrain,event,statut,max_companions,rsvp,rsvp_date,voucher_quota,quota_expiration,depth"+
 		" FROM Invite,Session"+
 		" WHERE id_invite = id_user AND token = ?",
 		token)
@@ -66,6 +66,14 @@ func VerifySession(db *sql.DB, token string) (modele.Invite, error) {
 			&i.Mdp, // Getting hashed password from database
 			&i.Numtel,
 			&i.Parrain,
+			&i.Event,
+			&i.Statut,
+			&i.MaxCompanions,
+			&i.Rsvp,
+			&i.RsvpDate,
+			&i.VoucherQuota,
+			&i.QuotaExpiration,
+			&i.Depth,
 		)
 		return i, err
 	}
@@ -91,10 +99,29 @@ func CreateAdminSession(db *sql.DB, idAdmin int64) (string, error) {
 	return randomString, err // Return the inserted token
 }
 
-// CreateAdminSession equivalent to VerifySession but for admin.
-func VerifyAdminSession(db *sql.DB, token string) (bool, error) {
-	result, err := db.Query("SELECT * FROM AdminSession WHERE token = ?", token)
+// VerifyAdminSession equivalent to VerifySession but for admin.
+// Return the admin modele, the role needs to be checked by the caller.
+func VerifyAdminSession(db *sql.DB, token string) (modele.Admin, error) {
+	var admin modele.Admin
+
+	result, err := db.Query("SELECT id_admin,login,role"+
+		" FROM Administrateur,AdminSession"+
+		" WHERE id_admin = id_user AND token = ?",
+		token)
+	if err != nil {
+		return admin, err
+	}
 	defer result.Close()
 
-	return result.Next(), err
+	if result.Next() { // No iteration because token should be unique
+		err = result.Scan(
+			&admin.IdAdmin,
+			&admin.Login,
+			&admin.Role,
+		)
+		admin.Token = token
+		return admin, err
+	}
+	err = errors.New("Verify admin session: No admin found") // Session has been deleted or incorrect token
+	return admin, err
 }
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DucNg/resa/config"
+)
+
+// privateKey is the server key used to sign invitations. Use getKey() to get it.
+var privateKey ed25519.PrivateKey
+
+// getKey load the signing key from the file provided in config.
+// The key is created on first use if the file doesn't exist. Keep this file secret, anyone having it can forge invitations.
+func getKey() (ed25519.PrivateKey, error) {
+	if privateKey != nil {
+		return privateKey, nil
+	}
+
+	seed, err := ioutil.ReadFile(*config.KeyFile)
+	if os.IsNotExist(err) { // First run, create the key
+		seed = make([]byte, ed25519.SeedSize)
+		_, err = rand.Read(seed)
+		if err != nil {
+			return nil, errors.New("Error generating random")
+		}
+		err = ioutil.WriteFile(*config.KeyFile, seed, 0600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("Invalid key file")
+	}
+
+	privateKey = ed25519.NewKeyFromSeed(seed)
+	return privateKey, nil
+}
+
+// invitationPayload is the signed message. It links the signature to an invite.
+func invitationPayload(idInvite int64) []byte {
+	return []byte("resa:invite:" + strconv.FormatInt(idInvite, 10))
+}
+
+// SignInvitation build the invitation code of an invite: his id and a signature of the server.
+// Example: 12.GcvoW7jDQk... The code is shown as a QR code on the invitation.
+func SignInvitation(idInvite int64) (string, error) {
+	key, err := getKey()
+	if err != nil {
+		return "", err
+	}
+
+	signature := ed25519.Sign(key, invitationPayload(idInvite))
+	return strconv.FormatInt(idInvite, 10) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyInvitation check the signature of an invitation code and return the invite id.
+// Complementatry to SignInvitation().
+func VerifyInvitation(code string) (int64, error) {
+	key, err := getKey()
+	if err != nil {
+		return -1, err
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(code), ".", 2)
+	if len(parts) != 2 {
+		return -1, errors.New("Invalid invitation")
+	}
+	idInvite, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return -1, errors.New("Invalid invitation")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return -1, errors.New("Invalid invitation")
+	}
+
+	if !ed25519.Verify(key.Public().(ed25519.PublicKey), invitationPayload(idInvite), signature) {
+		return -1, errors.New("Invalid invitation")
+	}
+	return idInvite, nil
+}
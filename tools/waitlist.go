@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/DucNg/resa/modele"
+)
+
+// freePlaces return the number of places left for an event.
+// Confirmed invites and their companions take one place each.
+// It use a transaction so the answer is still true when the caller write the invite.
+// An event with a capacity of 0 has no limit, -1 is returned.
+func freePlaces(tx *sql.Tx, idEvent int64) (int, error) {
+	var capacite int
+	err := tx.QueryRow("SELECT capacite FROM Event WHERE id_event = ?", idEvent).Scan(&capacite)
+	if err == sql.ErrNoRows { // No event means no limit
+		return -1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if capacite <= 0 {
+		return -1, nil
+	}
+
+	var confirmes int
+	err = tx.QueryRow("SELECT COUNT(*) FROM Invite WHERE event = ? AND statut = ?",
+		idEvent, modele.StatutConfirme).Scan(&confirmes)
+	if err != nil {
+		return 0, err
+	}
+
+	var companions int
+	err = tx.QueryRow("SELECT COUNT(*) FROM Companion,Invite"+
+		" WHERE Companion.invite = id_invite AND event = ? AND statut = ?",
+		idEvent, modele.StatutConfirme).Scan(&companions)
+	if err != nil {
+		return 0, err
+	}
+
+	if confirmes+companions >= capacite {
+		return 0, nil
+	}
+	return capacite - confirmes - companions, nil
+}
+
+// hasPlaces tell if there are enough free places for the number of persons asked.
+func hasPlaces(tx *sql.Tx, idEvent int64, persons int) (bool, error) {
+	free, err := freePlaces(tx, idEvent)
+	if err != nil {
+		return false, err
+	}
+	return free == -1 || free >= persons, nil
+}
+
+// PromoteWaitlist confirm invites from the waitlist as long as there are free places.
+// The first registered is the first promoted, with his companions.
+// If the first one doesn't fit with his companions nobody is promoted, he keeps his position.
+// It needs to be called every time a confirmed invite or a companion leave the event.
+func PromoteWaitlist(db *sql.DB, idEvent int64) error {
+	tx, err := db.Begin() // Start transaction
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Close transaction no matter what
+
+	for {
+		var idInvite int64
+		err = tx.QueryRow("SELECT id_invite FROM Invite WHERE event = ? AND statut = ?"+
+			" ORDER BY id_invite LIMIT 1", idEvent, modele.StatutAttente).Scan(&idInvite)
+		if err == sql.ErrNoRows { // Waitlist is empty
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var companions int
+		err = tx.QueryRow("SELECT COUNT(*) FROM Companion WHERE invite = ?", idInvite).Scan(&companions)
+		if err != nil {
+			return err
+		}
+
+		enough, err := hasPlaces(tx, idEvent, 1+companions)
+		if err != nil {
+			return err
+		}
+		if !enough {
+			break
+		}
+
+		_, err = tx.Exec("UPDATE Invite SET statut = ? WHERE id_invite = ?", modele.StatutConfirme, idInvite)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CancelInvite cancel the participation of an invite and give his place to the waitlist.
+// It's used when the invite cancel himself or when an admin remove him. A revoked invite stays revoked.
+func CancelInvite(db *sql.DB, idInvite int64) error {
+	invite, err := GetInvite(db, idInvite)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("UPDATE Invite SET statut = ? WHERE id_invite = ? AND statut != ?",
+		modele.StatutAnnule, idInvite, modele.StatutRevoque)
+	if err != nil {
+		return err
+	}
+
+	return PromoteWaitlist(db, invite.Event)
+}
+
+// WaitlistPosition return the position of an invite on the waitlist of his event, starting at 1.
+func WaitlistPosition(db *sql.DB, invite modele.Invite) (int, error) {
+	var position int
+	err := db.QueryRow("SELECT COUNT(*) FROM Invite WHERE event = ? AND statut = ? AND id_invite <= ?",
+		invite.Event, modele.StatutAttente, invite.Id).Scan(&position)
+	return position, err
+}
@@ -1,12 +1,15 @@
 package web
 
 import (
+	"database/sql"
 	"html/template"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/DucNg/resa/config"
 	"github.com/DucNg/resa/modele"
 	"github.com/DucNg/resa/tools"
 )
@@ -14,50 +17,96 @@ import (
 // Describe the admin structure page.
 // This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
 type page struct {
-	I                 modele.Invite
-	ParrainMail       string
-	VoucherCode       string
-	VoucherExpiration string
-	VoucherDisable    bool
+	I           modele.Invite
+	ParrainMail string
+	EventNom    string
+	CheckIn     modele.CheckIn
+	Companions  []modele.Companion
+	Answers     []modele.Answer
+	Vouchers    []modele.Voucher
+	Issuer      string // Login of the admin who issued the voucher of a root invite
+}
+
+// Describe a voucher issued by an admin, as shown on the admin page.
+type adminVoucher struct {
+	V        modele.Voucher
+	Issuer   string // Login of the admin
+	EventNom string
+}
+
+// Describe the whole admin page: the list of invite and the event filter.
+// Rsvp count the confirmed persons (invites and companions) for each RSVP answer.
+type adminPage struct {
+	Invites       []page
+	Events        []modele.Event
+	Event         int64 // Selected event, 0 means every events
+	Rsvp          map[string]int
+	AdminVouchers []adminVoucher
+}
+
+// Describe the add voucher page. The voucher can be linked to any event.
+// Prefix is the default prefix of generated codes. Message explains why the voucher was refused.
+type voucherPage struct {
+	I       modele.Invite
+	Events  []modele.Event
+	Prefix  string
+	Message string
+}
+
+// Maximum number of codes generated at once.
+const maxGenerated = 1000
+
+// Describe the page listing the codes generated for a parrain.
+type generatedPage struct {
+	I     modele.Invite
+	Codes []string
 }
 
 // AdminIndex handle the /admin page and redirect the user.
 // It shows the list of invite if the admin token is present and valid or the login page.
+// Staff accounts are redirected to the check-in page.
 func AdminIndex(w http.ResponseWriter, r *http.Request) {
-	validSession, err := verifySession(w, r)
-	if validSession {
-		AdminListInvite(w, r) // The token is valid show the administration page
+	admin, err := verifyStaffSession(r)
+	if err == nil {
+		if admin.Role == modele.RoleAdmin {
+			AdminListInvite(w, r) // The token is valid show the administration page
+		} else {
+			http.Redirect(w, r, "/checkin", http.StatusFound) // Staff can only check-in invites
+		}
 		return
 	}
-	if err != nil {
-		log.Println(err)
-	}
+	log.Println(err)
 	http.ServeFile(w, r, "html/adminLogin.html") // Invalid voucher redirect to login page
 }
 
 // verifySession verify the user session cookie.
 // It gets the local cookie first and then check it's validity on the database
+// Only admins are valid, staff accounts aren't.
 func verifySession(w http.ResponseWriter, r *http.Request) (bool, error) {
-	sessionToken, err := getAdminCookie(r)
+	admin, err := verifyStaffSession(r)
 	if err != nil {
 		return false, err
 	}
-	if sessionToken != "" {
-		// Connect to database first
-		db, err := tools.Connect()
-		if err != nil {
-			return false, err
-		}
-		defer tools.Disconnect(db)
+	return admin.Role == modele.RoleAdmin, nil
+}
 
-		// Verify token validity
-		validToken, err := tools.VerifyAdminSession(db, sessionToken) // Tell if the token is present in database
-		if err != nil {
-			return false, err
-		}
-		return validToken, err
+// verifyStaffSession verify the admin session cookie and return the connected account.
+// Admins and staff are both valid, the role needs to be checked by the caller.
+func verifyStaffSession(r *http.Request) (modele.Admin, error) {
+	sessionToken, err := getAdminCookie(r)
+	if err != nil {
+		return modele.Admin{}, err
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		return modele.Admin{}, err
 	}
-	return false, err // No valid token was found
+	defer tools.Disconnect(db)
+
+	// Verify token validity
+	return tools.VerifyAdminSession(db, sessionToken)
 }
 
 // AdminConnect connect an admin using login and password.
@@ -93,7 +142,7 @@ func AdminConnect(w http.ResponseWriter, r *http.Request) {
 	// We've created server side session but we still need to create the user cookie
 	setAdminCookie(w, user.Token)
 
-	// Redirect to admin page, will auto connect the useru using his token
+	// Redirect to admin page, will auto connect the useru using his token (staff will be redirected to check-in)
 	http.Redirect(w, r, "/admin", http.StatusFound)
 }
 
@@ -101,10 +150,16 @@ func AdminConnect(w http.ResponseWriter, r *http.Request) {
 // It shows parrain for every user linking idParrain to corresponding email.
 // It check if the invite has a voucher or not and show it's expiration date.
 // It also check if the voucher is disable.
+// The list can be filtered by event using the event parameter (GET).
 func AdminListInvite(w http.ResponseWriter, r *http.Request) {
 	var listInvite []modele.Invite
 	listInvite = make([]modele.Invite, 0) // Empty list of invite
 
+	idEvent, err := strconv.ParseInt(r.FormValue("event"), 10, 64) // Receive id_event from GET
+	if err != nil {
+		idEvent = 0 // No filter, show every events
+	}
+
 	// Connect to database first
 	db, err := tools.Connect()
 	if err != nil {
@@ -118,33 +173,90 @@ func AdminListInvite(w http.ResponseWriter, r *http.Request) {
 		log.Println(err) // Error in the select won't be critical, don't need to inform user
 	}
 
+	// Getting all the events for the filter
+	var listEvent []modele.Event
+	err = tools.ListEvents(db, &listEvent)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+
 	// Getting all the vouchers
-	var vouchers map[int64]modele.Voucher
-	vouchers = make(map[int64]modele.Voucher)
+	var vouchers map[int64][]modele.Voucher
+	vouchers = make(map[int64][]modele.Voucher)
 	err = tools.GetVouchers(db, vouchers)
 	if err != nil {
 		log.Println(err) // Error in the select won't be critical, don't need to inform user
 	}
-	// Get the parrain email and the voucher if the user has one
+	// Getting all the check-ins to show who came
+	var checkIns map[int64]modele.CheckIn
+	checkIns = make(map[int64]modele.CheckIn)
+	err = tools.GetCheckIns(db, checkIns)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+
+	// Getting all the companions to list them under their host
+	var companions map[int64][]modele.Companion
+	companions = make(map[int64][]modele.Companion)
+	err = tools.GetCompanions(db, companions)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+
+	// Getting all the answers to the registration questions
+	var answers map[int64][]modele.Answer
+	answers = make(map[int64][]modele.Answer)
+	err = tools.GetAnswers(db, answers)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+
+	// Getting the vouchers issued by admins and the voucher used by each invite to find who issued the root ones
+	var listAdminVoucher []modele.Voucher
+	err = tools.ListAdminVouchers(db, &listAdminVoucher)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+	logins := make(map[int64]string)
+	err = tools.GetAdminLogins(db, logins)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+	redemptions := make(map[int64]int64)
+	err = tools.GetRedemptions(db, redemptions)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+	issuers := make(map[int64]string) // Login of the issuer of each admin voucher
+	var av []adminVoucher
+	for _, voucher := range listAdminVoucher {
+		issuers[voucher.Id] = logins[voucher.Issuer]
+		if idEvent != 0 && voucher.Event != idEvent {
+			continue
+		}
+		av = append(av, adminVoucher{voucher, logins[voucher.Issuer], modele.GetEventNom(voucher.Event, listEvent)})
+	}
+
+	// Get the parrain email and the vouchers of the user
 	var p []page                         // Construct the page
+	rsvp := make(map[string]int)         // Count persons for each RSVP answer
 	for _, element := range listInvite { // Iterate on each invite
-		var tmpPage page
-		tmpParrainmail := modele.GetParrainMail(element.Parrain, listInvite) // Get the corresponding parrain mail for every Invite
-
-		if vouchers[element.Id].Code != "" {
-			tmpPage = page{
-				I:                 element,
-				ParrainMail:       tmpParrainmail,
-				VoucherCode:       vouchers[element.Id].Code,
-				VoucherExpiration: vouchers[element.Id].Expiration.Format(time.RFC822),    // Get the expiration date as a string
-				VoucherDisable:    vouchers[element.Id].Expiration.Equal(time.Unix(0, 0)), // Is voucher disable?
-			}
-		} else {
-			tmpPage = page{
-				I:           element,
-				ParrainMail: tmpParrainmail,
-				VoucherCode: vouchers[element.Id].Code,
-			}
+		if idEvent != 0 && element.Event != idEvent { // Filter by event, the complete list is still needed to get parrains
+			continue
+		}
+		tmpPage := page{
+			I:           element,
+			ParrainMail: modele.GetParrainMail(element.Parrain, listInvite), // Get the corresponding parrain mail for every Invite
+			EventNom:    modele.GetEventNom(element.Event, listEvent),       // Get the corresponding event name for every Invite
+			Vouchers:    vouchers[element.Id],                               // Empty if the invite has no voucher
+			Issuer:      issuers[redemptions[element.Id]],                   // Empty unless the invite registered with an admin's voucher
+		}
+
+		tmpPage.CheckIn = checkIns[element.Id] // Empty if the invite hasn't come
+		tmpPage.Companions = companions[element.Id]
+		tmpPage.Answers = answers[element.Id]
+		if element.Statut == modele.StatutConfirme {
+			rsvp[element.Rsvp] += 1 + len(tmpPage.Companions)
 		}
 
 		p = append(p, tmpPage) // List of invite with parrain
@@ -155,7 +267,7 @@ func AdminListInvite(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 	}
 
-	err = t.Execute(w, p) // Build and send page to user
+	err = t.Execute(w, adminPage{p, listEvent, idEvent, rsvp, av}) // Build and send page to user
 	if err != nil {
 		error502(w, err)
 		return
@@ -166,6 +278,8 @@ func AdminListInvite(w http.ResponseWriter, r *http.Request) {
 // This func is used in to situations:
 // * GET method: Provide the form page to enter informations on the voucher (code and expiration)
 // * POST method: Insert the voucher in database using informations from the form
+// Without id_invite the voucher is issued by the connected admin: it has no owner and is a root of the referral tree.
+// If no code is given, the number of vouchers asked are created with generated codes and the codes are shown.
 func AddVoucher(w http.ResponseWriter, r *http.Request) {
 	validSession, err := verifySession(w, r)
 	if err != nil {
@@ -176,11 +290,94 @@ func AddVoucher(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method == "GET" { // Send the form to select parameters
-		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_invite from GET
+		var id int64 // No id_invite means a voucher issued by the admin himself
+		if r.FormValue("id") != "" {
+			id, err = strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_invite from GET
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+
+		// Connect to database first
+		db, err := tools.Connect()
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		defer tools.Disconnect(db)
+
+		var Invite modele.Invite
+		if id != 0 {
+			Invite, err = tools.GetInvite(db, id)
+			if err != nil {
+				error502(w, err)
+				return
+			}
+		}
+
+		showAddVoucher(w, db, Invite, "")
+	} else if r.Method == "POST" {
+		r.ParseForm() // Getting informations from POST
+
+		expiration, err := parseFormDate(r.FormValue("expiration"))
+		log.Println(err)
+		var notBefore time.Time // Empty means usable right away
+		if r.FormValue("not_before") != "" {
+			notBefore, err = parseFormDate(r.FormValue("not_before"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+		prop, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // 0 means a voucher issued by the admin himself
+		log.Println(err)
+		admin, err := verifyStaffSession(r)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		event, err := strconv.ParseInt(r.FormValue("event"), 10, 64)
+		if err != nil {
+			error502(w, err) // A voucher without event can't be used to register
+			return
+		}
+		maxCompanions := -1 // Empty means use the event's
+		if r.FormValue("max_companions") != "" {
+			maxCompanions, err = strconv.Atoi(r.FormValue("max_companions"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+		maxUses := 0 // Empty means no limit
+		if r.FormValue("max_uses") != "" {
+			maxUses, err = strconv.Atoi(r.FormValue("max_uses"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+		auto, err := readPolicy(r, -1) // Empty means use the event's policy
 		if err != nil {
 			error502(w, err) // Show error to user and log it
 			return
 		}
+		// TODO handle errors (voucher in the past)
+
+		voucher := modele.Voucher{ // Fill the Invite struct with available informations
+			Code:          r.FormValue("code"),
+			Expiration:    expiration,
+			Prop:          prop,
+			Event:         event,
+			MaxCompanions: maxCompanions,
+			MaxUses:       maxUses,
+			NotBefore:     notBefore,
+			Auto:          auto,
+		}
+		if prop == 0 {
+			voucher.Issuer = admin.IdAdmin
+		}
 
 		// Connect to database first
 		db, err := tools.Connect()
@@ -190,35 +387,157 @@ func AddVoucher(w http.ResponseWriter, r *http.Request) {
 		}
 		defer tools.Disconnect(db)
 
-		Invite, err := tools.GetInvite(db, id)
+		_, err = tools.GetEvent(db, voucher.Event) // Check if the event exists
 		if err != nil {
-			error502(w, err)
+			error502(w, err) // Show error to user and log it
 			return
 		}
 
-		t, err := template.ParseFiles("html/addVoucher.hbs") // Load template
+		var parrain modele.Invite // Stays empty for a voucher issued by the admin
+		if prop != 0 {
+			parrain, err = tools.GetInvite(db, prop)
+			if err != nil {
+				error502(w, err)
+				return
+			}
+			if !parrain.CanSponsor(*config.MaxDepth) { // His invites would be too deep in the referral tree
+				depthError(w)
+				return
+			}
+		}
+
+		if voucher.Code != "" { // Code typed by the admin
+			err = tools.AddVoucher(db, voucher)
+			if err != nil {
+				if err.Error() == "Code already used" {
+					showAddVoucher(w, db, parrain, "Ce code est déjà utilisé par un autre voucher.")
+				} else {
+					error502(w, err) // Show error to user and log it
+				}
+				return
+			}
+
+			// Redirect to admin page
+			http.Redirect(w, r, "/admin", http.StatusFound)
+			return
+		}
+
+		count := 1 // Empty means a single code
+		if r.FormValue("nombre") != "" {
+			count, err = strconv.Atoi(r.FormValue("nombre"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+		if count < 1 || count > maxGenerated {
+			showAddVoucher(w, db, parrain, "Le nombre de codes à générer doit être entre 1 et "+strconv.Itoa(maxGenerated)+".")
+			return
+		}
+		prefix := strings.ToUpper(r.FormValue("prefix"))
+		codes, err := tools.GenerateVouchers(db, voucher, prefix, *config.CodeLength, count)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		t, err := template.ParseFiles("html/generatedVouchers.hbs") // Load template
 		if err != nil {
 			log.Println(err)
 		}
 
-		err = t.Execute(w, Invite) // Build and send page to user
+		err = t.Execute(w, generatedPage{parrain, codes}) // Build and send page to user
 		if err != nil {
 			error502(w, err)
 			return
 		}
-	} else if r.Method == "POST" {
-		r.ParseForm() // Getting informations from POST
+	} else {
+		error404(w)
+	}
+}
 
-		expiration, err := time.Parse("2006-01-02T15:04", r.FormValue("expiration"))
+// showAddVoucher show the form to add a voucher to an invite, or an admin's voucher if the invite is empty.
+func showAddVoucher(w http.ResponseWriter, db *sql.DB, invite modele.Invite, message string) {
+	var listEvent []modele.Event
+	err := tools.ListEvents(db, &listEvent)
+	if err != nil {
+		error502(w, err)
+		return
+	}
+
+	t, err := template.ParseFiles("html/addVoucher.hbs") // Load template
+	if err != nil {
 		log.Println(err)
-		prop, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	}
+
+	err = t.Execute(w, voucherPage{invite, listEvent, *config.CodePrefix, message}) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
+
+// AddStaff is the controller to create a staff account.
+// Staff accounts can only use the check-in page.
+// * GET method: Provide the form page to enter login and password
+// * POST method: Insert the account in database
+func AddStaff(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
 		log.Println(err)
-		// TODO handle errors (voucher in the past)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+	if r.Method == "GET" {
+		http.ServeFile(w, r, "html/addStaff.hbs")
+	} else if r.Method == "POST" {
+		r.ParseForm() // Getting informations from POST
 
-		voucher := modele.Voucher{ // Fill the Invite struct with available informations
-			Code:       r.FormValue("code"),
-			Expiration: expiration,
-			Prop:       prop,
+		staff := modele.Admin{
+			Login: r.FormValue("login"),
+			Psw:   r.FormValue("mdp"),
+			Role:  modele.RoleStaff,
+		}
+
+		// Connect to database first
+		db, err := tools.Connect()
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		defer tools.Disconnect(db)
+
+		_, err = tools.CreateAdmin(db, &staff)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		// Redirect to admin page
+		http.Redirect(w, r, "/admin", http.StatusFound)
+	} else {
+		error404(w)
+	}
+}
+
+// RemoveInvite cancel the participation of an invite using his id.
+// His place is given to the first invite on the waitlist.
+func RemoveInvite(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+	if r.Method == "GET" {
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_invite from GET
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
 		}
 
 		// Connect to database first
@@ -229,7 +548,7 @@ func AddVoucher(w http.ResponseWriter, r *http.Request) {
 		}
 		defer tools.Disconnect(db)
 
-		err = tools.AddVoucher(db, voucher)
+		err = tools.CancelInvite(db, id)
 		if err != nil {
 			error502(w, err) // Show error to user and log it
 			return
@@ -242,8 +561,8 @@ func AddVoucher(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// DisableVoucher using a user id
-// Disable means set is expiration date to UNIX timestamp 0
+// DisableVoucher using a voucher id, the other vouchers of the owner stay valid
+// Disable means set his statut to desactive, the expiration date is kept so it can be enabled again
 // TODO show a confirmation page before disabling
 func DisableVoucher(w http.ResponseWriter, r *http.Request) {
 	validSession, err := verifySession(w, r)
@@ -255,7 +574,7 @@ func DisableVoucher(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method == "GET" {
-		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_invite from GET
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_voucher from GET
 		if err != nil {
 			error502(w, err) // Show error to user and log it
 			return
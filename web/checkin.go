@@ -0,0 +1,100 @@
+package web
+
+import (
+	"database/sql"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// Describe the check-in page. Resultat is the answer shown to the staff after checking a code:
+// "accepte", "deja" (already checked-in), "attente" (waitlisted), "revoque" or "invalide". It's empty before the first check.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type checkInPage struct {
+	Login    string
+	Resultat string
+	I        modele.Invite
+	C        modele.CheckIn
+}
+
+// CheckIn handle the /checkin page used by the staff at the door.
+// * GET method: Show the form to enter or scan an invitation code
+// * POST method: Check the code signature and the invite status then record his arrival
+// Available for staff and admin accounts.
+func CheckIn(w http.ResponseWriter, r *http.Request) {
+	staff, err := verifyStaffSession(r)
+	if err != nil { // This page is only available if connected as staff or admin
+		log.Println(err)
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+
+	p := checkInPage{Login: staff.Login}
+
+	if r.Method == "POST" {
+		r.ParseForm() // Getting informations from POST
+
+		// Connect to database first
+		db, err := tools.Connect()
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		defer tools.Disconnect(db)
+
+		p.Resultat, err = checkInCode(db, r.FormValue("code"), staff, &p)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+	} else if r.Method != "GET" {
+		error404(w)
+		return
+	}
+
+	t, err := template.ParseFiles("html/checkin.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = t.Execute(w, p) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
+
+// checkInCode check an invitation code and record the arrival of the invite if it's valid.
+// It fills the invite and the check-in of the page and return the answer to show.
+// Only database errors are returned as error, an invalid code is a normal answer.
+func checkInCode(db *sql.DB, code string, staff modele.Admin, p *checkInPage) (string, error) {
+	idInvite, err := tools.VerifyInvitation(code)
+	if err != nil {
+		log.Println(err)
+		return "invalide", nil // Forged or mistyped code
+	}
+
+	p.I, err = tools.GetInvite(db, idInvite)
+	if err != nil {
+		log.Println(err)
+		return "invalide", nil // Signed by us but the invite has been deleted
+	}
+	if p.I.Statut == modele.StatutAttente {
+		return "attente", nil // Still on the waitlist, there's no place for him yet
+	}
+	if p.I.Statut != modele.StatutConfirme {
+		return "revoque", nil // Cancelled or removed invite, the printed invitation isn't valid anymore
+	}
+
+	p.C, err = tools.CheckInInvite(db, idInvite, staff.IdAdmin)
+	if err != nil {
+		if err.Error() == "Already checked in" {
+			return "deja", nil
+		}
+		return "", err
+	}
+	return "accepte", nil
+}
@@ -4,6 +4,11 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DucNg/resa/config"
+	"github.com/DucNg/resa/modele"
 )
 
 // Handle errors. Show error to user a log them.
@@ -103,6 +108,139 @@ func voucherExpired(w http.ResponseWriter) {
 	t.Execute(w, p) // Build and send page to user
 }
 
+func voucherMistyped(w http.ResponseWriter) {
+	log.Println("Voucher mistyped")
+
+	p := errorPage{"Voucher invalide", "Ce code contient une faute de frappe, vérifiez-le et réessayez"}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func voucherDisabled(w http.ResponseWriter) {
+	log.Println("Voucher disabled")
+
+	p := errorPage{"Voucher invalide", "Ce voucher a été désactivé"}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func voucherNotYetValid(w http.ResponseWriter, notBefore time.Time) {
+	log.Println("Voucher not yet valid")
+
+	p := errorPage{"Inscriptions pas encore ouvertes", "Les inscriptions ouvrent le " +
+		modele.FormatDate(notBefore) + " à " + modele.FormatHeure(notBefore)}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func quotaError(w http.ResponseWriter) {
+	log.Println("No voucher left")
+
+	p := errorPage{"Code refusé", "Vous avez déjà créé tous les codes auxquels vous aviez droit."}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func quotaExpirationError(w http.ResponseWriter, max time.Time) {
+	log.Println("Invalid expiration")
+
+	p := errorPage{"Code refusé", "La date d'expiration doit être dans le futur et au plus tard le " +
+		modele.FormatDate(max) + " à " + modele.FormatHeure(max) + "."}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func depthError(w http.ResponseWriter) {
+	log.Println("Depth exceeded")
+
+	p := errorPage{"Parrainage impossible", "La chaîne de parrainage a atteint sa longueur maximale (" +
+		strconv.Itoa(*config.MaxDepth) + " niveaux) : ce code ne permet plus d'inviter de nouvelles personnes."}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func voucherUsedUp(w http.ResponseWriter) {
+	log.Println("Voucher used up")
+
+	p := errorPage{"Voucher invalide", "Ce voucher a atteint son nombre maximum d'utilisations"}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func companionLimitError(w http.ResponseWriter) {
+	log.Println("Too many companions")
+
+	p := errorPage{"Accompagnant refusé", "Vous avez atteint le nombre maximum d'accompagnants."}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func eventFullError(w http.ResponseWriter) {
+	log.Println("Event full")
+
+	p := errorPage{"Événement complet", "Il n'y a plus de place disponible pour un accompagnant."}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
+func resetTokenError(w http.ResponseWriter) {
+	log.Println("Invalid token")
+
+	p := errorPage{"Lien invalide", "Ce lien de réinitialisation a expiré ou a déjà été utilisé. Vous pouvez en demander un nouveau depuis la page de connexion."}
+
+	t, err := template.ParseFiles("html/error.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	t.Execute(w, p) // Build and send page to user
+}
+
 func passwordError(w http.ResponseWriter) {
 	log.Println("Connect attempt: incorrect password")
 
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// AddEvent is the controller to add an event.
+// This func is used in to situations:
+// * GET method: Provide the form page to enter informations on the event
+// * POST method: Insert the event in database using informations from the form
+func AddEvent(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+	if r.Method == "GET" { // Send the form to fill the event
+		t, err := template.ParseFiles("html/addEvent.hbs") // Load template
+		if err != nil {
+			log.Println(err)
+		}
+
+		err = t.Execute(w, nil) // Build and send page to user
+		if err != nil {
+			error502(w, err)
+			return
+		}
+	} else if r.Method == "POST" {
+		r.ParseForm() // Getting informations from POST
+
+		debut, err := parseFormDate(r.FormValue("debut"))
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		fin, err := parseFormDate(r.FormValue("fin"))
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		capacite := 0 // Empty capacity means no limit
+		if r.FormValue("capacite") != "" {
+			capacite, err = strconv.Atoi(r.FormValue("capacite"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+
+		maxCompanions := 0 // Empty means no companion
+		if r.FormValue("max_companions") != "" {
+			maxCompanions, err = strconv.Atoi(r.FormValue("max_companions"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+
+		auto, err := readPolicy(r, 0) // Empty means no automatic voucher
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		event := modele.Event{ // Fill the Event struct with informations from the form
+			Nom:           r.FormValue("nom"),
+			Description:   r.FormValue("description"),
+			Lieu:          r.FormValue("lieu"),
+			Debut:         debut,
+			Fin:           fin,
+			Capacite:      capacite,
+			MaxCompanions: maxCompanions,
+			Auto:          auto,
+		}
+
+		// Connect to database first
+		db, err := tools.Connect()
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		defer tools.Disconnect(db)
+
+		_, err = tools.AddEvent(db, event)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		// Redirect to admin page
+		http.Redirect(w, r, "/admin", http.StatusFound)
+	} else {
+		error404(w)
+	}
+}
+
+// readPolicy get an automatic voucher policy from a form (auto_uses, auto_days and auto_depth).
+// An empty auto_uses gives noUses, other empty values give 0.
+func readPolicy(r *http.Request, noUses int) (modele.Policy, error) {
+	policy := modele.Policy{MaxUses: noUses}
+	var err error
+	if r.FormValue("auto_uses") != "" {
+		policy.MaxUses, err = strconv.Atoi(r.FormValue("auto_uses"))
+		if err != nil {
+			return policy, err
+		}
+	}
+	if r.FormValue("auto_days") != "" {
+		policy.Days, err = strconv.Atoi(r.FormValue("auto_days"))
+		if err != nil {
+			return policy, err
+		}
+	}
+	if r.FormValue("auto_depth") != "" {
+		policy.MaxDepth, err = strconv.Atoi(r.FormValue("auto_depth"))
+		if err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
+
+// parseFormDate parse a date typed in a datetime-local input.
+// Every date of the forms is in the server's time zone, like the dates read from the database.
+func parseFormDate(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02T15:04", value, time.Local)
+}
@@ -0,0 +1,103 @@
+package web
+
+import (
+	"encoding/csv"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// ExportInvites send the list of invites of an event (id_event in GET) as a CSV file.
+// There is one column for each registration question of the event.
+// The separator is a semicolon so the file opens directly in a french spreadsheet.
+func ExportInvites(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+
+	idEvent, err := strconv.ParseInt(r.FormValue("event"), 10, 64) // Receive id_event from GET
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	var listInvite []modele.Invite
+	err = tools.ListInvite(db, &listInvite)
+	if err != nil {
+		error502(w, err)
+		return
+	}
+
+	var fields []modele.Field
+	err = tools.ListFields(db, idEvent, &fields)
+	if err != nil {
+		error502(w, err)
+		return
+	}
+
+	answers := make(map[int64][]modele.Answer)
+	err = tools.GetAnswers(db, answers)
+	if err != nil {
+		error502(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"invites-"+strconv.FormatInt(idEvent, 10)+".csv\"")
+
+	out := csv.NewWriter(w)
+	out.Comma = ';'
+
+	header := []string{"Nom", "Prénom", "Email", "Téléphone", "Statut", "Réponse"}
+	for _, field := range fields {
+		header = append(header, csvCell(field.Libelle))
+	}
+	out.Write(header)
+
+	for _, invite := range listInvite {
+		if invite.Event != idEvent {
+			continue
+		}
+		line := []string{csvCell(invite.Nom), csvCell(invite.Prenom), csvCell(invite.Mail), csvCell(invite.Numtel), invite.Statut, invite.Rsvp}
+		for _, field := range fields { // Keep the columns order even if some answers are missing
+			valeur := ""
+			for _, answer := range answers[invite.Id] {
+				if answer.Field == field.Id {
+					valeur = answer.Valeur
+				}
+			}
+			line = append(line, csvCell(valeur))
+		}
+		out.Write(line)
+	}
+	out.Flush()
+	if err = out.Error(); err != nil {
+		log.Println(err) // Headers are already sent, can't show an error page
+	}
+}
+
+// csvCell protect a value typed by a guest before writing it in a CSV file.
+// Spreadsheets run cells starting with =, +, - or @ as formulas, a quote in front makes them plain text.
+func csvCell(valeur string) string {
+	if valeur != "" && strings.ContainsRune("=+-@", rune(valeur[0])) {
+		return "'" + valeur
+	}
+	return valeur
+}
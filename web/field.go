@@ -0,0 +1,134 @@
+package web
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// Describe the page of the registration fields of an event.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type fieldsPage struct {
+	E      modele.Event
+	Fields []modele.Field
+}
+
+// Fields is the controller of the extra questions asked on registration for an event.
+// * GET method: Show the questions of the event (id_event in GET) and the form to add one
+// * POST method: Insert the question in database using informations from the form
+func Fields(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+
+	r.ParseForm() // Getting informations from GET or POST
+	idEvent, err := strconv.ParseInt(r.FormValue("event"), 10, 64)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	if r.Method == "GET" {
+		event, err := tools.GetEvent(db, idEvent)
+		if err != nil {
+			error502(w, err)
+			return
+		}
+
+		var fields []modele.Field
+		err = tools.ListFields(db, idEvent, &fields)
+		if err != nil {
+			error502(w, err)
+			return
+		}
+
+		t, err := template.ParseFiles("html/fields.hbs") // Load template
+		if err != nil {
+			log.Println(err)
+		}
+
+		err = t.Execute(w, fieldsPage{event, fields}) // Build and send page to user
+		if err != nil {
+			error502(w, err)
+			return
+		}
+	} else if r.Method == "POST" {
+		field := modele.Field{ // Fill the Field struct with informations from the form
+			Event:       idEvent,
+			Libelle:     r.FormValue("libelle"),
+			Type:        r.FormValue("type"),
+			Options:     r.FormValue("options"),
+			Obligatoire: r.FormValue("obligatoire") != "",
+		}
+		if !modele.ValidFieldType(field.Type) {
+			error404(w)
+			return
+		}
+
+		err = tools.AddField(db, field)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		// Redirect to the questions of the event
+		http.Redirect(w, r, "/fields?event="+strconv.FormatInt(idEvent, 10), http.StatusFound)
+	} else {
+		error404(w)
+	}
+}
+
+// DeleteField delete an extra question and the answers given to it, using his id.
+func DeleteField(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+	if r.Method == "GET" {
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_field from GET
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		// Connect to database first
+		db, err := tools.Connect()
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		defer tools.Disconnect(db)
+
+		err = tools.DeleteField(db, id)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		// Redirect to the questions of the event
+		http.Redirect(w, r, "/fields?event="+r.FormValue("event"), http.StatusFound)
+	} else {
+		error404(w)
+	}
+}
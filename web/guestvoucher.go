@@ -0,0 +1,168 @@
+package web
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DucNg/resa/tools"
+)
+
+// CreateVoucher create a voucher for the connected user, within the quota given by an admin.
+// The expiration comes from the form (POST), it can't be after the maximum given by the admin.
+func CreateVoucher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		error404(w)
+		return
+	}
+	r.ParseForm() // Getting informations from POST
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil || user.Termine() {
+		http.Redirect(w, r, "/", http.StatusFound) // User needs to connect first
+		return
+	}
+
+	expiration, err := parseFormDate(r.FormValue("expiration"))
+	if err != nil {
+		expiration = user.QuotaExpiration // Empty means as long as possible
+	}
+
+	_, err = tools.CreateGuestVoucher(db, user, expiration)
+	if err != nil {
+		if err.Error() == "No voucher left" {
+			quotaError(w)
+		} else if err.Error() == "Invalid expiration" {
+			quotaExpirationError(w, user.QuotaExpiration)
+		} else if err.Error() == "Depth exceeded" {
+			depthError(w)
+		} else {
+			error502(w, err) // Show error to user and log it
+		}
+		return
+	}
+
+	// Redirect to home page
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// RevokeVoucher disable one of the connected user's vouchers (id_voucher in POST).
+func RevokeVoucher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		error404(w)
+		return
+	}
+	r.ParseForm() // Getting informations from POST
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil {
+		http.Redirect(w, r, "/", http.StatusFound) // User needs to connect first
+		return
+	}
+
+	err = tools.RevokeVoucher(db, id, user.Id)
+	if err != nil {
+		if err.Error() == "Voucher doesn't exist" { // Not one of the user's vouchers
+			error404(w)
+		} else {
+			error502(w, err) // Show error to user and log it
+		}
+		return
+	}
+
+	// Redirect to home page
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// SetQuota is the controller to allow an invite (id_invite in GET or POST) to create his own vouchers.
+// * GET method: Provide the form page to enter the number of vouchers and their maximum expiration
+// * POST method: Save the quota of the invite
+func SetQuota(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+
+	r.ParseForm() // Getting informations from GET or POST
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	if r.Method == "GET" {
+		invite, err := tools.GetInvite(db, id)
+		if err != nil {
+			error502(w, err)
+			return
+		}
+
+		t, err := template.ParseFiles("html/setQuota.hbs") // Load template
+		if err != nil {
+			log.Println(err)
+		}
+
+		err = t.Execute(w, invite) // Build and send page to user
+		if err != nil {
+			error502(w, err)
+			return
+		}
+	} else if r.Method == "POST" {
+		quota, err := strconv.Atoi(r.FormValue("quota"))
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		expiration, err := parseFormDate(r.FormValue("expiration"))
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		err = tools.SetQuota(db, id, quota, expiration)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+
+		// Redirect to admin page
+		http.Redirect(w, r, "/admin", http.StatusFound)
+	} else {
+		error404(w)
+	}
+}
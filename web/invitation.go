@@ -0,0 +1,177 @@
+package web
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
+
+	"github.com/DucNg/resa/config"
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// QRCode send the QR code of the connected user's invitation as a PNG image.
+// The QR code contains the invitation code signed by the server, see tools.SignInvitation().
+// Only confirmed invites have an invitation.
+func QRCode(w http.ResponseWriter, r *http.Request) {
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil || user.Statut != modele.StatutConfirme {
+		error404(w) // No invitation for this user
+		return
+	}
+
+	code, err := tools.SignInvitation(user.Id)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	png, err := qrcode.Encode(code, qrcode.Medium, 256)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store") // The image depends on the session
+	w.Write(png)
+}
+
+// InvitationPDF send the invitation of the connected user as a PDF document.
+// The document is built on the server so it's the same in every browser, no external service is used.
+// Only confirmed invites have an invitation.
+func InvitationPDF(w http.ResponseWriter, r *http.Request) {
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil || user.Statut != modele.StatutConfirme {
+		error404(w) // No invitation for this user
+		return
+	}
+
+	event, err := tools.GetEvent(db, user.Event)
+	if err != nil {
+		error404(w) // No event, no invitation
+		return
+	}
+
+	code, err := tools.SignInvitation(user.Id)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	var companions []modele.Companion
+	err = tools.ListCompanions(db, user.Id, &companions)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	var pdf bytes.Buffer // Build the document first so an error can still be shown
+	err = writeInvitationPDF(&pdf, user, event, companions, code)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"invitation.pdf\"")
+	w.Write(pdf.Bytes())
+}
+
+// writeInvitationPDF write the invitation as a PDF document. It's the same layout as the invitation on the user page.
+// Images are read from the html folder and the QR code is generated, nothing is downloaded.
+func writeInvitationPDF(out io.Writer, user modele.Invite, event modele.Event, companions []modele.Companion, code string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // Core fonts aren't UTF-8, translate to cp1252
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+
+	// image add a centered image and move under it
+	image := func(name string, width float64) {
+		pdf.ImageOptions(name, (pageWidth-width)/2, pdf.GetY(), width, 0, true, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		pdf.Ln(5)
+	}
+	// text add a centered line of text
+	text := func(style string, size float64, txt string) {
+		pdf.SetFont("Helvetica", style, size)
+		pdf.MultiCell(0, size/2, tr(txt), "", "C", false)
+		pdf.Ln(3)
+	}
+
+	image("html/img/logo.png", 50)
+	text("B", 22, "Vous convie à "+event.Nom)
+	image("html/img/sos.png", 40)
+	if event.Description != "" {
+		text("", 12, event.Description)
+	}
+	text("B", 16, event.Horaires())
+	text("B", 16, user.Prenom+" "+user.Nom)
+	text("", 14, user.Mail)
+	if len(companions) > 0 {
+		names := make([]string, 0, len(companions))
+		for _, companion := range companions {
+			names = append(names, companion.Prenom+" "+companion.Nom)
+		}
+		text("", 14, "Accompagné de "+strings.Join(names, ", "))
+	}
+	text("", 14, event.Lieu)
+
+	png, err := qrcode.Encode(code, qrcode.Medium, 512)
+	if err != nil {
+		return err
+	}
+	pdf.RegisterImageOptionsReader("qrcode", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+	image("qrcode", 50)
+	text("", 6, code)
+
+	return pdf.Output(out) // Output also return any error encountered while building
+}
+
+// InvitationICS send the event of the connected user as an iCalendar (.ics) file.
+// The user is found using his session like ConnectToken() does.
+func InvitationICS(w http.ResponseWriter, r *http.Request) {
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil || user.Termine() {
+		error404(w) // No event for this user
+		return
+	}
+
+	event, err := tools.GetEvent(db, user.Event)
+	if err != nil {
+		error404(w) // No event for this user
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"invitation.ics\"")
+	io.WriteString(w, modele.BuildICS(event, user, *config.URL+"/"))
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DucNg/resa/config"
+	"github.com/DucNg/resa/tools"
+)
+
+// Describe the forgotten password pages. Done tells the request was handled.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type passwordPage struct {
+	Token   string // Reset token from the link, empty on the request page
+	Message string
+	Done    bool
+}
+
+// ForgotPassword is the controller to ask for a password reset.
+// * GET method: Provide the form page to enter the email
+// * POST method: Send a link to reset the password if an invite uses this email
+// The answer is the same whether the email exists or not so it can't be used to find who is registered.
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		showPasswordPage(w, "html/forgotPassword.hbs", passwordPage{})
+	} else if r.Method == "POST" {
+		r.ParseForm() // Getting informations from POST
+
+		// Connect to database first
+		db, err := tools.Connect()
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		defer tools.Disconnect(db)
+
+		invite, token, err := tools.CreateResetToken(db, r.FormValue("mail"))
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		if token != "" {
+			body := "Bonjour " + invite.Prenom + ",\n\n" +
+				"Pour choisir un nouveau mot de passe, ouvrez ce lien :\n" +
+				*config.URL + "/resetPassword?token=" + token + "\n\n" +
+				"Ce lien est valable " + strconv.Itoa(*config.ResetValidity) + " minutes et ne peut servir qu'une fois.\n" +
+				"Si vous n'avez pas demandé à changer votre mot de passe, ignorez ce message.\n"
+			go func() { // Don't make the user wait for the mail server, it would tell the email exists
+				err := tools.NewMailer().Send(invite.Mail, "Réinitialisation de votre mot de passe", body)
+				if err != nil {
+					log.Println(err) // The user can ask for another link, the unused token expires on its own
+				}
+			}()
+		}
+
+		showPasswordPage(w, "html/forgotPassword.hbs", passwordPage{Done: true, Message: "Si un compte correspond à cette adresse, " +
+			"un mail contenant un lien pour choisir un nouveau mot de passe vient d'être envoyé."})
+	} else {
+		error404(w)
+	}
+}
+
+// ResetPassword is the controller to choose a new password using the link sent by ForgotPassword (token in GET or POST).
+// * GET method: Provide the form page to enter the new password if the link is still valid
+// * POST method: Change the password, the link can't be used again
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm() // Getting informations from GET or POST
+	token := r.FormValue("token")
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	if r.Method == "GET" {
+		err = tools.CheckResetToken(db, token)
+		if err != nil {
+			if err.Error() == "Invalid token" {
+				resetTokenError(w)
+			} else {
+				error502(w, err) // Show error to user and log it
+			}
+			return
+		}
+		showPasswordPage(w, "html/resetPassword.hbs", passwordPage{Token: token})
+	} else if r.Method == "POST" {
+		if r.FormValue("mdp") == "" || r.FormValue("mdp") != r.FormValue("mdp2") {
+			showPasswordPage(w, "html/resetPassword.hbs", passwordPage{Token: token, Message: "Les mots de passe ne correspondent pas."})
+			return
+		}
+
+		err = tools.ResetPassword(db, token, r.FormValue("mdp"))
+		if err != nil {
+			if err.Error() == "Invalid token" {
+				resetTokenError(w)
+			} else {
+				error502(w, err) // Show error to user and log it
+			}
+			return
+		}
+		showPasswordPage(w, "html/resetPassword.hbs", passwordPage{Done: true, Message: "Votre mot de passe a été changé, vous pouvez vous connecter."})
+	} else {
+		error404(w)
+	}
+}
+
+// showPasswordPage show one of the forgotten password pages.
+func showPasswordPage(w http.ResponseWriter, file string, p passwordPage) {
+	t, err := template.ParseFiles(file) // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = t.Execute(w, p) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
@@ -1,15 +1,35 @@
 package web
 
 import (
+	"database/sql"
+	"html/template"
+	"log"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/DucNg/resa/modele"
 	"github.com/DucNg/resa/tools"
 )
 
+// Describe the register page. It's shown when the event asks extra questions.
+// Informations already given are filled again, except the password.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type registerPage struct {
+	I       modele.Invite
+	Voucher string
+	Fields  []modele.Field
+	Answers map[int64]string
+	Message string
+	Locked  bool // The voucher comes from a shared link and can't be changed
+}
+
 // Register get informations from a form, verify these informations and build a modele using them.
 // It inserts informations into the database.
 // It makes the association between invite and parrain.
+// The invite gets his own voucher if the automatic voucher policy of the voucher or of the event allows it.
+// If the event has extra questions it shows the complete register form until they're answered.
 // It create the user session (client side and server side).
 // It redirect user to index (he will be automatically connected using the token)
 func Register(w http.ResponseWriter, r *http.Request) {
@@ -56,16 +76,14 @@ func Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check voucher validity and make association
-	voucher := r.FormValue("voucher")
+	voucher, err := tools.FindVoucherCode(db, r.FormValue("voucher")) // Generated codes can be typed in lower case or with dashes
+	if err != nil {
+		showVoucherError(w, db, voucher, err)
+		return
+	}
 	idParrain, err := tools.GetParrain(db, voucher)
 	if err != nil { // Database error
-		if err.Error() == "Voucher expired" {
-			voucherExpired(w)
-		} else if err.Error() == "Voucher doesn't exist" {
-			voucherError(w) // Show error to user
-		} else {
-			error502(w, err) // Show error to user and log it
-		}
+		showVoucherError(w, db, voucher, err)
 		return
 	}
 	if idParrain == -1 { // If parrain is -1 mean no voucher was found
@@ -73,16 +91,86 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user.Parrain = idParrain // User now has a parrain
+	user.Parrain = idParrain // User now has a parrain, 0 if the voucher was issued by an admin
+	user.Depth = 1           // Invites of an admin's voucher are at the root of the referral tree
+	if idParrain != 0 {
+		parrain, err := tools.GetInvite(db, idParrain)
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		user.Depth = parrain.Depth + 1 // One level below his parrain in the referral tree
+	}
+
+	// The invite is registered to the event of the voucher
+	usedVoucher, err := tools.GetVoucher(db, voucher)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	user.Event = usedVoucher.Event
+	event, err := tools.GetEvent(db, user.Event)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// The number of companions is given by the voucher, or by the event if the voucher doesn't say
+	user.MaxCompanions = usedVoucher.MaxCompanions
+	if user.MaxCompanions < 0 {
+		user.MaxCompanions = event.MaxCompanions
+	}
+
+	// Extra questions of the event, the form of the index page doesn't have them
+	var fields []modele.Field
+	err = tools.ListFields(db, user.Event, &fields)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	answers, message := readAnswers(r, fields)
+	if len(fields) > 0 && r.FormValue("fields") == "" { // Questions haven't been shown yet
+		message = "Merci de compléter les informations demandées par l'organisateur."
+	}
+	if message != "" {
+		p := registerPage{user, voucher, fields, make(map[int64]string), message, r.FormValue("locked") != ""}
+		for _, answer := range answers {
+			p.Answers[answer.Field] = answer.Valeur
+		}
+		showRegisterForm(w, p)
+		return
+	}
 
 	// If everything is valid, writting informations to database and get the user id
-	userId, err := tools.CreateUser(db, &user) // userId will be used when session will be implemented
+	redemption := modele.Redemption{
+		Voucher: usedVoucher.Id,
+		Date:    time.Now(),
+		IP:      clientIP(r),
+	}
+	userId, err := tools.CreateUser(db, &user, redemption) // userId will be used when session will be implemented
 	//_,err = tools.CreateUser(db,&user)
+	if err != nil {
+		if err.Error() == "Voucher used up" { // Someone took the last use since the voucher was checked
+			voucherUsedUp(w)
+		} else {
+			error502(w, err) // Show error to user and log it
+		}
+		return
+	}
+
+	err = tools.SaveAnswers(db, userId, answers)
 	if err != nil {
 		error502(w, err) // Show error to user and log it
 		return
 	}
 
+	// Give him a voucher right away if the policy of the voucher or of the event says so
+	user.Id = userId
+	_, err = tools.IssueVoucher(db, user, event, usedVoucher.Policy(event), usedVoucher.Auto)
+	if err != nil {
+		log.Println(err) // The invite is registered anyway, an admin can still give him a voucher
+	}
+
 	// Create session
 	token, err := tools.CreateSession(db, userId)
 	if err != nil { // Error generating token
@@ -95,3 +183,105 @@ func Register(w http.ResponseWriter, r *http.Request) {
 	// Redirect to home page
 	http.Redirect(w, r, "/", http.StatusFound)
 }
+
+// RegisterLink show the register form for a voucher shared as a link (/r/{code}).
+// The voucher and the depth of the referral chain are checked first so the user knows right away if he can't register with it.
+// If it's valid the form is shown with the voucher filled and locked, along with the questions of the event.
+func RegisterLink(w http.ResponseWriter, r *http.Request) {
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	voucher, err := tools.FindVoucherCode(db, strings.TrimPrefix(r.URL.Path, "/r/"))
+	if err != nil {
+		showVoucherError(w, db, voucher, err)
+		return
+	}
+	_, err = tools.GetParrain(db, voucher)
+	if err != nil {
+		showVoucherError(w, db, voucher, err)
+		return
+	}
+
+	usedVoucher, err := tools.GetVoucher(db, voucher)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	var fields []modele.Field
+	err = tools.ListFields(db, usedVoucher.Event, &fields)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	showRegisterForm(w, registerPage{modele.Invite{}, voucher, fields, make(map[int64]string), "", true})
+}
+
+// showVoucherError show the error page matching the error returned when checking a voucher.
+func showVoucherError(w http.ResponseWriter, db *sql.DB, code string, err error) {
+	if err.Error() == "Voucher mistyped" {
+		voucherMistyped(w)
+	} else if err.Error() == "Voucher expired" {
+		voucherExpired(w)
+	} else if err.Error() == "Voucher used up" {
+		voucherUsedUp(w)
+	} else if err.Error() == "Voucher disabled" {
+		voucherDisabled(w)
+	} else if err.Error() == "Voucher not yet valid" {
+		notYet, err := tools.GetVoucher(db, code) // Get the opening date to show it
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		voucherNotYetValid(w, notYet.NotBefore)
+	} else if err.Error() == "Depth exceeded" {
+		depthError(w)
+	} else if err.Error() == "Voucher doesn't exist" {
+		voucherError(w) // Show error to user
+	} else {
+		error502(w, err) // Show error to user and log it
+	}
+}
+
+// clientIP return the IP address of the user, without the port.
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// readAnswers get the answers to the extra questions from the form and check them.
+// Return the answers and a message for the user if an answer is missing or invalid.
+func readAnswers(r *http.Request, fields []modele.Field) ([]modele.Answer, string) {
+	var answers []modele.Answer
+	var message string
+	for _, field := range fields {
+		valeur := r.FormValue(field.FormName())
+		if field.CheckAnswer(valeur) != nil && message == "" { // Only show the first error
+			message = "Veuillez répondre à la question : " + field.Libelle
+		}
+		answers = append(answers, modele.Answer{Field: field.Id, Libelle: field.Libelle, Valeur: valeur})
+	}
+	return answers, message
+}
+
+// showRegisterForm show the complete register form with the extra questions of the event.
+func showRegisterForm(w http.ResponseWriter, p registerPage) {
+	t, err := template.ParseFiles("html/register.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = t.Execute(w, p) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"encoding/csv"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// Describe the referral leaderboard page, it can be filtered by event like the admin page.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type statsPage struct {
+	Stats  []modele.ParrainStats
+	Events []modele.Event
+	Event  int64  // Selected event, 0 means every events
+	Sort   string // Column used to sort the leaderboard
+}
+
+// ReferralStats show the referral leaderboard: for each parrain his direct referrals, his whole downstream,
+// the referrals who confirmed or checked in and the conversion rate of each of his vouchers.
+// The list can be filtered by event (GET event) and sorted (GET sort, one of modele.StatsSorts).
+func ReferralStats(w http.ResponseWriter, r *http.Request) {
+	stats, idEvent, by, ok := loadStats(w, r)
+	if !ok {
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	// Getting all the events for the filter
+	var listEvent []modele.Event
+	err = tools.ListEvents(db, &listEvent)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+
+	t, err := template.ParseFiles("html/stats.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = t.Execute(w, statsPage{stats, listEvent, idEvent, by}) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
+
+// ExportStats send the referral leaderboard as a CSV file, one line per voucher.
+// Parrains without any voucher still get a line with empty voucher columns.
+func ExportStats(w http.ResponseWriter, r *http.Request) {
+	stats, _, _, ok := loadStats(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"parrainage-stats.csv\"")
+
+	out := csv.NewWriter(w)
+	out.Comma = ';'
+
+	out.Write([]string{"Nom", "Prénom", "Email", "Filleuls directs", "Descendance totale", "Filleuls confirmés", "Filleuls présents",
+		"Voucher", "Inscriptions", "Confirmés", "Présents", "Conversion (%)"})
+	for _, s := range stats {
+		line := []string{csvCell(s.I.Nom), csvCell(s.I.Prenom), csvCell(s.I.Mail), strconv.Itoa(s.Directs), strconv.Itoa(s.Downstream),
+			strconv.Itoa(s.Confirmes), strconv.Itoa(s.Presents)}
+		if len(s.Vouchers) == 0 {
+			out.Write(append(line, "", "", "", "", ""))
+			continue
+		}
+		for _, v := range s.Vouchers {
+			out.Write(append(line, v.V.Code, strconv.Itoa(v.Inscrits), strconv.Itoa(v.Confirmes),
+				strconv.Itoa(v.Presents), strconv.Itoa(v.Conversion())))
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Println(err) // Headers are already sent, can't show an error page
+	}
+}
+
+// loadStats check the admin session and compute the sorted referral leaderboard, filtered by event (GET).
+// Return false if a response was already sent to the user.
+func loadStats(w http.ResponseWriter, r *http.Request) ([]modele.ParrainStats, int64, string, bool) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return nil, 0, "", false
+	}
+
+	idEvent, err := strconv.ParseInt(r.FormValue("event"), 10, 64) // Receive id_event from GET
+	if err != nil {
+		idEvent = 0 // No filter, show every events
+	}
+
+	by := modele.StatsSorts[0] // Unknown columns fall back to direct referrals
+	for _, column := range modele.StatsSorts {
+		if r.FormValue("sort") == column {
+			by = column
+		}
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return nil, 0, "", false
+	}
+	defer tools.Disconnect(db)
+
+	var listInvite []modele.Invite
+	err = tools.ListInvite(db, &listInvite)
+	if err != nil {
+		error502(w, err)
+		return nil, 0, "", false
+	}
+
+	if idEvent != 0 { // Same filter as the referral tree
+		var filtered []modele.Invite
+		for _, element := range listInvite {
+			if element.Event == idEvent {
+				filtered = append(filtered, element)
+			}
+		}
+		listInvite = filtered
+	}
+
+	vouchers := make(map[int64][]modele.Voucher)
+	err = tools.GetVouchers(db, vouchers)
+	if err != nil {
+		error502(w, err)
+		return nil, 0, "", false
+	}
+
+	checkIns := make(map[int64]modele.CheckIn)
+	err = tools.GetCheckIns(db, checkIns)
+	if err != nil {
+		error502(w, err)
+		return nil, 0, "", false
+	}
+
+	redemptions := make(map[int64]int64)
+	err = tools.GetRedemptions(db, redemptions)
+	if err != nil {
+		error502(w, err)
+		return nil, 0, "", false
+	}
+
+	stats := modele.BuildStats(listInvite, vouchers, checkIns, redemptions)
+	modele.SortStats(stats, by)
+	return stats, idEvent, by, true
+}
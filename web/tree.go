@@ -0,0 +1,139 @@
+package web
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// Describe the referral tree page, it can be filtered by event like the admin page.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type treePage struct {
+	Roots  []*modele.Node
+	Events []modele.Event
+	Event  int64 // Selected event, 0 means every events
+	Total  int   // Number of invites in the tree
+}
+
+// ReferralTree show the whole referral tree: who invited whom, depth and size of each subtree.
+// The list can be filtered by event using the event parameter (GET).
+func ReferralTree(w http.ResponseWriter, r *http.Request) {
+	roots, idEvent, ok := loadTree(w, r)
+	if !ok {
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	// Getting all the events for the filter
+	var listEvent []modele.Event
+	err = tools.ListEvents(db, &listEvent)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+
+	total := 0
+	for _, root := range roots {
+		total += root.Size
+	}
+
+	t, err := template.ParseFiles("html/tree.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = t.Execute(w, treePage{roots, listEvent, idEvent, total}) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
+
+// ReferralTreeJSON send the referral tree as a JSON file.
+func ReferralTreeJSON(w http.ResponseWriter, r *http.Request) {
+	roots, _, ok := loadTree(w, r)
+	if !ok {
+		return
+	}
+	if roots == nil {
+		roots = []*modele.Node{} // Empty list rather than null
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"parrainage.json\"")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(roots)
+	if err != nil {
+		log.Println(err) // Headers are already sent, can't show an error page
+	}
+}
+
+// ReferralTreeDOT send the referral tree as a Graphviz file. It can be drawn with: dot -Tsvg parrainage.dot
+func ReferralTreeDOT(w http.ResponseWriter, r *http.Request) {
+	roots, _, ok := loadTree(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"parrainage.dot\"")
+	io.WriteString(w, modele.BuildDOT(roots))
+}
+
+// loadTree check the admin session and build the referral tree of the invites, filtered by event (GET).
+// Return false if a response was already sent to the user.
+func loadTree(w http.ResponseWriter, r *http.Request) ([]*modele.Node, int64, bool) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return nil, 0, false
+	}
+
+	idEvent, err := strconv.ParseInt(r.FormValue("event"), 10, 64) // Receive id_event from GET
+	if err != nil {
+		idEvent = 0 // No filter, show every events
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return nil, 0, false
+	}
+	defer tools.Disconnect(db)
+
+	var listInvite []modele.Invite
+	err = tools.ListInvite(db, &listInvite)
+	if err != nil {
+		error502(w, err)
+		return nil, 0, false
+	}
+
+	if idEvent != 0 { // Invites sponsored by someone of another event become roots
+		var filtered []modele.Invite
+		for _, element := range listInvite {
+			if element.Event == idEvent {
+				filtered = append(filtered, element)
+			}
+		}
+		listInvite = filtered
+	}
+
+	return modele.BuildTree(listInvite), idEvent, true
+}
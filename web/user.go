@@ -1,14 +1,30 @@
 package web
 
 import (
+	"database/sql"
 	"html/template"
 	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/DucNg/resa/config"
 	"github.com/DucNg/resa/modele"
 	"github.com/DucNg/resa/tools"
 )
 
+// Describe the user page.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type userPage struct {
+	I          modele.Invite
+	E          modele.Event
+	Position   int    // Position on the waitlist
+	Code       string // Signed invitation code, also in the QR code
+	Companions []modele.Companion
+	Vouchers   []modele.Voucher  // Vouchers owned by the user
+	URL        string            // Public address of the site, used to build the links to share the vouchers
+	Referrals  []modele.Referral // Invites who registered with the user's vouchers
+}
+
 // Connect using mail and password
 // Get informations from the connection form on index page.
 // Verify informations (show error), create session, redirect to /
@@ -87,6 +103,16 @@ func ConnectToken(w http.ResponseWriter, r *http.Request, token string) {
 	showUserPage(w, user)
 }
 
+// connectedUser return the invite of the session cookie.
+// It's the same verification as ConnectToken() for pages that needs a connected user.
+func connectedUser(db *sql.DB, r *http.Request) (modele.Invite, error) {
+	token, err := getSessionCookie(r)
+	if err != nil {
+		return modele.Invite{}, err
+	}
+	return tools.VerifySession(db, token)
+}
+
 // Build and show user page. Use invite modele to fill the informations on the page.
 func showUserPage(w http.ResponseWriter, user modele.Invite) {
 	// Getting the user's voucher if exist
@@ -98,20 +124,203 @@ func showUserPage(w http.ResponseWriter, user modele.Invite) {
 	}
 	defer tools.Disconnect(db)
 
-	// Getting all the vouchers
-	var vouchers map[int64]modele.Voucher
-	vouchers = make(map[int64]modele.Voucher)
-	err = tools.GetVouchers(db, vouchers)
+	// Getting the user's vouchers
+	var vouchers []modele.Voucher
+	err = tools.ListVouchers(db, user.Id, &vouchers)
+	if err != nil {
+		log.Println(err)
+	}
 
-	// Select the user's voucher
-	user.Voucher = vouchers[user.Id].Code
+	// Getting the invites the user has sponsored
+	var referrals []modele.Referral
+	err = tools.ListReferrals(db, user.Id, &referrals)
+	if err != nil {
+		log.Println(err)
+	}
+
+	// Getting the event the user is invited to
+	event, err := tools.GetEvent(db, user.Event)
+	if err != nil {
+		log.Println(err) // The invitation won't be shown, don't need to inform user
+	}
+
+	// Getting the position on the waitlist if the event was full
+	var position int
+	if user.Statut == modele.StatutAttente {
+		position, err = tools.WaitlistPosition(db, user)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	// Getting the companions of the user
+	var companions []modele.Companion
+	err = tools.ListCompanions(db, user.Id, &companions)
+	if err != nil {
+		log.Println(err)
+	}
+
+	// Signed code of the invitation, it can be typed at the door if the QR code can't be scanned
+	code, err := tools.SignInvitation(user.Id)
+	if err != nil {
+		log.Println(err)
+	}
 
 	t, err := template.ParseFiles("html/userpage.hbs") // Load template
 	if err != nil {
 		log.Println(err)
 	}
 
-	t.Execute(w, user) // Build and send page to user
+	t.Execute(w, userPage{user, event, position, code, companions, vouchers, *config.URL, referrals}) // Build and send page to user
+}
+
+// Cancel the participation of the connected user.
+// His place is given to the first invite on the waitlist.
+func Cancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		error404(w)
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil {
+		http.Redirect(w, r, "/", http.StatusFound) // User needs to connect first
+		return
+	}
+
+	err = tools.CancelInvite(db, user.Id)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Redirect to home page, the user page shows the cancellation
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// Rsvp change the RSVP answer of the connected user: attending, declined or maybe.
+func Rsvp(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		error404(w)
+		return
+	}
+	r.ParseForm() // Getting informations from POST
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil {
+		http.Redirect(w, r, "/", http.StatusFound) // User needs to connect first
+		return
+	}
+
+	err = tools.SetRsvp(db, user.Id, r.FormValue("rsvp"))
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Redirect to home page
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// AddCompanion add a companion to the connected user using informations from the form.
+// The number of companions is limited by the voucher or the event, and companions take places on the event.
+func AddCompanion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		error404(w)
+		return
+	}
+	r.ParseForm() // Getting informations from POST
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil || user.Termine() {
+		http.Redirect(w, r, "/", http.StatusFound) // User needs to connect first
+		return
+	}
+
+	companion := modele.Companion{ // Fill the Companion struct with informations from the form
+		Invite: user.Id,
+		Nom:    r.FormValue("nom"),
+		Prenom: r.FormValue("prenom"),
+		Mail:   r.FormValue("mail"),
+		Numtel: r.FormValue("numtel"),
+	}
+
+	_, err = tools.AddCompanion(db, companion)
+	if err != nil {
+		if err.Error() == "Too many companions" {
+			companionLimitError(w)
+		} else if err.Error() == "Event full" {
+			eventFullError(w)
+		} else {
+			error502(w, err) // Show error to user and log it
+		}
+		return
+	}
+
+	// Redirect to home page
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// DeleteCompanion delete a companion of the connected user.
+func DeleteCompanion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		error404(w)
+		return
+	}
+	r.ParseForm() // Getting informations from POST
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_companion from POST
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	user, err := connectedUser(db, r)
+	if err != nil {
+		http.Redirect(w, r, "/", http.StatusFound) // User needs to connect first
+		return
+	}
+
+	err = tools.DeleteCompanion(db, id, user)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Redirect to home page
+	http.Redirect(w, r, "/", http.StatusFound)
 }
 
 // Disconnect the user. Delete the session token, client side and server side.
@@ -0,0 +1,284 @@
+package web
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DucNg/resa/modele"
+	"github.com/DucNg/resa/tools"
+)
+
+// Describe the page of a voucher with the list of invites who used it.
+// This isn't a modele! It is only used to build the visual aspect of the page for the user (frontend).
+type voucherHistoryPage struct {
+	V           modele.Voucher
+	P           modele.Invite // Owner of the voucher
+	Issuer      string        // Login of the admin who issued the voucher, if he did
+	EventNom    string
+	Redemptions []modele.Redemption
+}
+
+// VoucherHistory show a voucher (id_voucher in GET) and every registration done with it.
+// It is used to audit how a code was spread.
+func VoucherHistory(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64) // Receive id_voucher from GET
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	voucher, err := tools.GetVoucherById(db, id)
+	if err != nil {
+		error502(w, err)
+		return
+	}
+
+	var parrain modele.Invite
+	var issuer string
+	if voucher.Issuer != 0 { // Issued by an admin, there's no owner
+		logins := make(map[int64]string)
+		err = tools.GetAdminLogins(db, logins)
+		if err != nil {
+			log.Println(err) // Error in the select won't be critical, don't need to inform user
+		}
+		issuer = logins[voucher.Issuer]
+	} else {
+		parrain, err = tools.GetInvite(db, voucher.Prop)
+		if err != nil {
+			log.Println(err) // Error in the select won't be critical, don't need to inform user
+		}
+	}
+
+	event, err := tools.GetEvent(db, voucher.Event)
+	if err != nil {
+		log.Println(err) // Error in the select won't be critical, don't need to inform user
+	}
+
+	var redemptions []modele.Redemption
+	err = tools.ListRedemptions(db, id, &redemptions)
+	if err != nil {
+		error502(w, err)
+		return
+	}
+
+	t, err := template.ParseFiles("html/voucher.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = t.Execute(w, voucherHistoryPage{voucher, parrain, issuer, event.Nom, redemptions}) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
+
+// Describe the edit voucher page. Message explains why the changes were refused.
+type editVoucherPage struct {
+	V       modele.Voucher
+	Message string
+}
+
+// EditVoucher is the controller to change a voucher (id_voucher in GET or POST).
+// * GET method: Provide the form page filled with the voucher's informations
+// * POST method: Change the code, activation and expiration dates, redemption limit, automatic voucher policy and status of the voucher. A disabled voucher can be enabled again.
+func EditVoucher(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+
+	r.ParseForm() // Getting informations from GET or POST
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	voucher, err := tools.GetVoucherById(db, id)
+	if err != nil {
+		error502(w, err)
+		return
+	}
+
+	if r.Method == "GET" {
+		showEditVoucher(w, editVoucherPage{voucher, ""})
+	} else if r.Method == "POST" {
+		voucher.Expiration, err = parseFormDate(r.FormValue("expiration"))
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		voucher.NotBefore = time.Time{} // Empty means usable right away
+		if r.FormValue("not_before") != "" {
+			voucher.NotBefore, err = parseFormDate(r.FormValue("not_before"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+		voucher.MaxUses = 0 // Empty means no limit
+		if r.FormValue("max_uses") != "" {
+			voucher.MaxUses, err = strconv.Atoi(r.FormValue("max_uses"))
+			if err != nil {
+				error502(w, err) // Show error to user and log it
+				return
+			}
+		}
+		voucher.Auto, err = readPolicy(r, -1) // Empty means use the event's policy
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		voucher.Code = r.FormValue("code")
+		voucher.Statut = modele.VoucherActif
+		if r.FormValue("statut") == modele.VoucherDesactive {
+			voucher.Statut = modele.VoucherDesactive
+		}
+
+		if voucher.Code == "" {
+			showEditVoucher(w, editVoucherPage{voucher, "Le code ne peut pas être vide."})
+			return
+		}
+
+		err = tools.UpdateVoucher(db, voucher)
+		if err != nil {
+			if err.Error() == "Code already used" {
+				showEditVoucher(w, editVoucherPage{voucher, "Ce code est déjà utilisé par un autre voucher."})
+			} else {
+				error502(w, err) // Show error to user and log it
+			}
+			return
+		}
+
+		// Redirect to the voucher page
+		http.Redirect(w, r, "/voucher?id="+strconv.FormatInt(id, 10), http.StatusFound)
+	} else {
+		error404(w)
+	}
+}
+
+// showEditVoucher show the form to change a voucher.
+func showEditVoucher(w http.ResponseWriter, p editVoucherPage) {
+	t, err := template.ParseFiles("html/editVoucher.hbs") // Load template
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = t.Execute(w, p) // Build and send page to user
+	if err != nil {
+		error502(w, err)
+		return
+	}
+}
+
+// Describe the cascading revocation page: the voucher and everyone who would be affected.
+type revokeChainPage struct {
+	V          modele.Voucher
+	Downstream []modele.Downstream
+	Vouchers   map[int64][]modele.Voucher // Vouchers of the downstream invites
+}
+
+// RevokeChain is the controller to revoke a leaked voucher (id_voucher in GET or POST) and what comes from it.
+// * GET method: Preview every invite who registered with the voucher, directly or through the referral chain, with the options
+// * POST method: Disable the voucher and, if asked, the vouchers of the downstream invites and the invites themselves
+func RevokeChain(w http.ResponseWriter, r *http.Request) {
+	validSession, err := verifySession(w, r)
+	if err != nil {
+		log.Println(err)
+	}
+	if !validSession { // This action is only available if connected as an admin
+		http.Redirect(w, r, "/admin", http.StatusFound) // Invalid session redirect to login page
+		return
+	}
+
+	r.ParseForm() // Getting informations from GET or POST
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+
+	// Connect to database first
+	db, err := tools.Connect()
+	if err != nil {
+		error502(w, err) // Show error to user and log it
+		return
+	}
+	defer tools.Disconnect(db)
+
+	if r.Method == "GET" {
+		voucher, err := tools.GetVoucherById(db, id)
+		if err != nil {
+			error502(w, err)
+			return
+		}
+
+		downstream, err := tools.GetDownstream(db, id)
+		if err != nil {
+			error502(w, err)
+			return
+		}
+
+		vouchers := make(map[int64][]modele.Voucher)
+		err = tools.GetVouchers(db, vouchers)
+		if err != nil {
+			log.Println(err) // Error in the select won't be critical, don't need to inform user
+		}
+
+		t, err := template.ParseFiles("html/revokeChain.hbs") // Load template
+		if err != nil {
+			log.Println(err)
+		}
+
+		err = t.Execute(w, revokeChainPage{voucher, downstream, vouchers}) // Build and send page to user
+		if err != nil {
+			error502(w, err)
+			return
+		}
+	} else if r.Method == "POST" {
+		n, err := tools.RevokeChain(db, id, r.FormValue("vouchers") != "", r.FormValue("invites") != "")
+		if err != nil {
+			error502(w, err) // Show error to user and log it
+			return
+		}
+		log.Println("Voucher", id, "revoked with", n, "downstream invites")
+
+		// Redirect to the voucher page
+		http.Redirect(w, r, "/voucher?id="+strconv.FormatInt(id, 10), http.StatusFound)
+	} else {
+		error404(w)
+	}
+}